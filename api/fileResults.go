@@ -0,0 +1,114 @@
+package api
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/ElrondNetwork/statistics-go/data"
+)
+
+type fileResults struct {
+	transactionsFile string
+	accountsFile     string
+	stakeFile        string
+
+	mutResults   sync.RWMutex
+	transactions []*data.StatisticsEpoch
+	accounts     []*data.StatisticsAddressesBalanceEpoch
+	stake        []*data.StakeInfoEpoch
+}
+
+// NewFileResults will create a new instance of fileResults that holds the statistics read from the json output
+// files. A missing file means that the statistic was not generated yet
+func NewFileResults(transactionsFile string, accountsFile string, stakeFile string) (*fileResults, error) {
+	fr := &fileResults{
+		transactionsFile: transactionsFile,
+		accountsFile:     accountsFile,
+		stakeFile:        stakeFile,
+	}
+
+	err := fr.Refresh()
+	if err != nil {
+		return nil, err
+	}
+
+	return fr, nil
+}
+
+// Refresh will read again the output files, the previous results are kept if any file cannot be read
+func (fr *fileResults) Refresh() error {
+	transactions := make([]*data.StatisticsEpoch, 0)
+	err := readRecords(fr.transactionsFile, &transactions)
+	if err != nil {
+		return err
+	}
+
+	accounts := make([]*data.StatisticsAddressesBalanceEpoch, 0)
+	err = readRecords(fr.accountsFile, &accounts)
+	if err != nil {
+		return err
+	}
+
+	stake := make([]*data.StakeInfoEpoch, 0)
+	err = readRecords(fr.stakeFile, &stake)
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(transactions, func(i, j int) bool {
+		return transactions[i].Epoch < transactions[j].Epoch
+	})
+	sort.Slice(accounts, func(i, j int) bool {
+		return accounts[i].Epoch < accounts[j].Epoch
+	})
+	sort.Slice(stake, func(i, j int) bool {
+		return stake[i].Epoch < stake[j].Epoch
+	})
+
+	fr.mutResults.Lock()
+	fr.transactions = transactions
+	fr.accounts = accounts
+	fr.stake = stake
+	fr.mutResults.Unlock()
+
+	return nil
+}
+
+// Transactions returns the latest transactions statistics
+func (fr *fileResults) Transactions() []*data.StatisticsEpoch {
+	fr.mutResults.RLock()
+	defer fr.mutResults.RUnlock()
+
+	return fr.transactions
+}
+
+// Accounts returns the latest accounts statistics
+func (fr *fileResults) Accounts() []*data.StatisticsAddressesBalanceEpoch {
+	fr.mutResults.RLock()
+	defer fr.mutResults.RUnlock()
+
+	return fr.accounts
+}
+
+// Stake returns the latest stake statistics
+func (fr *fileResults) Stake() []*data.StakeInfoEpoch {
+	fr.mutResults.RLock()
+	defer fr.mutResults.RUnlock()
+
+	return fr.stake
+}
+
+func readRecords(filePath string, records interface{}) error {
+	bytes, err := ioutil.ReadFile(filePath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(bytes, records)
+}
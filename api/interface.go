@@ -0,0 +1,16 @@
+package api
+
+import "github.com/ElrondNetwork/statistics-go/data"
+
+// ResultsHolder defines what a holder of the latest generated statistics should be able to do. The records are
+// ordered by epoch
+type ResultsHolder interface {
+	Transactions() []*data.StatisticsEpoch
+	Accounts() []*data.StatisticsAddressesBalanceEpoch
+	Stake() []*data.StakeInfoEpoch
+}
+
+// StakeBalancesProvider defines what a source of the staked balances of every epoch should be able to do
+type StakeBalancesProvider interface {
+	LoadStakeBalances(epoch uint32) (map[string]string, error)
+}
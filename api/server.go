@@ -0,0 +1,236 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	stakeBalancesPath = "/stats/stake/balances/"
+	shutdownTimeout   = 5 * time.Second
+)
+
+type stakeBalanceResponse struct {
+	Address string `json:"address"`
+	Epoch   uint32 `json:"epoch"`
+	Balance string `json:"balance"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+type server struct {
+	httpServer    *http.Server
+	mux           *http.ServeMux
+	results       ResultsHolder
+	stakeBalances StakeBalancesProvider
+}
+
+// NewServer will create a new instance of server that exposes the statistics over http. Every statistic accepts
+// the optional from and to query parameters, an inclusive range of epochs
+func NewServer(listenAddress string, results ResultsHolder, stakeBalances StakeBalancesProvider) (*server, error) {
+	if listenAddress == "" {
+		return nil, fmt.Errorf("empty listen address")
+	}
+	if results == nil {
+		return nil, fmt.Errorf("nil results holder")
+	}
+	if stakeBalances == nil {
+		return nil, fmt.Errorf("nil stake balances provider")
+	}
+
+	s := &server{
+		results:       results,
+		stakeBalances: stakeBalances,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/stats/transactions", s.transactionsHandler)
+	mux.HandleFunc("/stats/accounts", s.accountsHandler)
+	mux.HandleFunc("/stats/stake", s.stakeHandler)
+	mux.HandleFunc(stakeBalancesPath, s.stakeBalancesHandler)
+
+	s.mux = mux
+	s.httpServer = &http.Server{
+		Addr:    listenAddress,
+		Handler: mux,
+	}
+
+	return s, nil
+}
+
+// RegisterHandler will serve the provided handler on a path besides the statistics, e.g. the metrics
+func (s *server) RegisterHandler(path string, handler http.Handler) {
+	s.mux.Handle(path, handler)
+}
+
+// Start will serve the requests until the server is closed
+func (s *server) Start() error {
+	log.Printf("starting statistics api on %s", s.httpServer.Addr)
+
+	err := s.httpServer.ListenAndServe()
+	if err == http.ErrServerClosed {
+		return nil
+	}
+
+	return err
+}
+
+// Close will stop the server, waiting for the requests in progress
+func (s *server) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	return s.httpServer.Shutdown(ctx)
+}
+
+func (s *server) transactionsHandler(w http.ResponseWriter, r *http.Request) {
+	records := s.results.Transactions()
+	start, end, err := epochsWindow(r, len(records), func(idx int) uint32 {
+		return records[idx].Epoch
+	})
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, records[start:end])
+}
+
+func (s *server) accountsHandler(w http.ResponseWriter, r *http.Request) {
+	records := s.results.Accounts()
+	start, end, err := epochsWindow(r, len(records), func(idx int) uint32 {
+		return records[idx].Epoch
+	})
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, records[start:end])
+}
+
+func (s *server) stakeHandler(w http.ResponseWriter, r *http.Request) {
+	records := s.results.Stake()
+	start, end, err := epochsWindow(r, len(records), func(idx int) uint32 {
+		return records[idx].Epoch
+	})
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, records[start:end])
+}
+
+// stakeBalancesHandler returns the staked balance of an address in the epoch from the query, or in the last epoch
+// of the stake statistics when no epoch is provided
+func (s *server) stakeBalancesHandler(w http.ResponseWriter, r *http.Request) {
+	address := strings.TrimPrefix(r.URL.Path, stakeBalancesPath)
+	if address == "" || strings.Contains(address, "/") {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid address"))
+		return
+	}
+
+	epoch, err := s.balancesEpoch(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	balances, err := s.stakeBalances.LoadStakeBalances(epoch)
+	if err != nil {
+		writeError(w, http.StatusNotFound, fmt.Errorf("no stake balances for epoch %d", epoch))
+		return
+	}
+
+	balance, ok := balances[address]
+	if !ok {
+		balance = "0"
+	}
+
+	writeJSON(w, http.StatusOK, &stakeBalanceResponse{
+		Address: address,
+		Epoch:   epoch,
+		Balance: balance,
+	})
+}
+
+func (s *server) balancesEpoch(r *http.Request) (uint32, error) {
+	epochStr := r.URL.Query().Get("epoch")
+	if epochStr != "" {
+		return parseEpoch("epoch", epochStr)
+	}
+
+	stake := s.results.Stake()
+	if len(stake) == 0 {
+		return 0, fmt.Errorf("no stake statistics generated yet")
+	}
+
+	return stake[len(stake)-1].Epoch, nil
+}
+
+// epochsWindow returns the indexes of the records, ordered by epoch, that are in the range of the from and to
+// query parameters
+func epochsWindow(r *http.Request, numRecords int, epochAt func(idx int) uint32) (int, int, error) {
+	query := r.URL.Query()
+
+	start := 0
+	if fromStr := query.Get("from"); fromStr != "" {
+		from, err := parseEpoch("from", fromStr)
+		if err != nil {
+			return 0, 0, err
+		}
+		start = sort.Search(numRecords, func(idx int) bool {
+			return epochAt(idx) >= from
+		})
+	}
+
+	end := numRecords
+	if toStr := query.Get("to"); toStr != "" {
+		to, err := parseEpoch("to", toStr)
+		if err != nil {
+			return 0, 0, err
+		}
+		end = sort.Search(numRecords, func(idx int) bool {
+			return epochAt(idx) > to
+		})
+	}
+
+	if end < start {
+		return 0, 0, fmt.Errorf("from is greater than to")
+	}
+
+	return start, end, nil
+}
+
+func parseEpoch(name string, value string) (uint32, error) {
+	epoch, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s epoch %s", name, value)
+	}
+
+	return uint32(epoch), nil
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, &errorResponse{Error: err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, status int, response interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	err := json.NewEncoder(w).Encode(response)
+	if err != nil {
+		log.Printf("cannot write response, error %s", err.Error())
+	}
+}
@@ -0,0 +1,110 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"testing"
+
+	"github.com/ElrondNetwork/statistics-go/data"
+)
+
+type stakeBalancesProviderStub struct {
+	balancesByEpoch map[uint32]map[string]string
+}
+
+func (sbps *stakeBalancesProviderStub) LoadStakeBalances(epoch uint32) (map[string]string, error) {
+	balances, ok := sbps.balancesByEpoch[epoch]
+	if !ok {
+		return nil, fmt.Errorf("missing epoch %d", epoch)
+	}
+
+	return balances, nil
+}
+
+func createTestServer(t *testing.T) *server {
+	folder := t.TempDir()
+	transactionsFile := path.Join(folder, "output-transactions.json")
+	stakeFile := path.Join(folder, "output-stake.json")
+
+	writeTestRecords(t, transactionsFile, []*data.StatisticsEpoch{{Epoch: 3}, {Epoch: 1}, {Epoch: 2}, {Epoch: 5}})
+	writeTestRecords(t, stakeFile, []*data.StakeInfoEpoch{{Epoch: 4, TotalStaked: "100"}, {Epoch: 5, TotalStaked: "200"}})
+
+	results, err := NewFileResults(transactionsFile, path.Join(folder, "output-accounts.json"), stakeFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := NewServer(":0", results, &stakeBalancesProviderStub{
+		balancesByEpoch: map[uint32]map[string]string{
+			4: {"erd1a": "10"},
+			5: {"erd1a": "20"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return s
+}
+
+func writeTestRecords(t *testing.T, filePath string, records interface{}) {
+	bytes, _ := json.Marshal(records)
+	err := ioutil.WriteFile(filePath, bytes, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func doRequest(s *server, url string, response interface{}) int {
+	recorder := httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, url, nil))
+	_ = json.Unmarshal(recorder.Body.Bytes(), response)
+
+	return recorder.Code
+}
+
+func TestServer_StatisticsRange(t *testing.T) {
+	s := createTestServer(t)
+
+	transactions := make([]*data.StatisticsEpoch, 0)
+	status := doRequest(s, "/stats/transactions?from=2&to=4", &transactions)
+	if status != http.StatusOK || len(transactions) != 2 || transactions[0].Epoch != 2 || transactions[1].Epoch != 3 {
+		t.Fatalf("unexpected response %d %v", status, transactions)
+	}
+
+	accounts := make([]*data.StatisticsAddressesBalanceEpoch, 0)
+	status = doRequest(s, "/stats/accounts", &accounts)
+	if status != http.StatusOK || len(accounts) != 0 {
+		t.Fatalf("unexpected response %d %v", status, accounts)
+	}
+
+	errResponse := &errorResponse{}
+	status = doRequest(s, "/stats/stake?from=a", errResponse)
+	if status != http.StatusBadRequest || errResponse.Error == "" {
+		t.Fatalf("unexpected response %d %v", status, errResponse)
+	}
+}
+
+func TestServer_StakeBalances(t *testing.T) {
+	s := createTestServer(t)
+
+	balance := &stakeBalanceResponse{}
+	status := doRequest(s, "/stats/stake/balances/erd1a", balance)
+	if status != http.StatusOK || balance.Epoch != 5 || balance.Balance != "20" {
+		t.Fatalf("unexpected response %d %v", status, balance)
+	}
+
+	status = doRequest(s, "/stats/stake/balances/erd1b?epoch=4", balance)
+	if status != http.StatusOK || balance.Epoch != 4 || balance.Balance != "0" {
+		t.Fatalf("unexpected response %d %v", status, balance)
+	}
+
+	status = doRequest(s, "/stats/stake/balances/erd1a?epoch=7", &errorResponse{})
+	if status != http.StatusNotFound {
+		t.Fatalf("unexpected status %d", status)
+	}
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/ElrondNetwork/statistics-go/output"
+	"github.com/ElrondNetwork/statistics-go/process"
+	"github.com/ElrondNetwork/statistics-go/statistics"
+	"github.com/urfave/cli"
+)
+
+// daemonCommand follows the chain and generates all the statistics of every epoch once it is closed
+var daemonCommand = cli.Command{
+	Name:   "daemon",
+	Usage:  "Generates all the statistics of every closed epoch, continuing from the saved checkpoints",
+	Action: startDaemon,
+}
+
+type epochsFollower struct {
+	statsHandler        statistics.StatsHandler
+	currentEpochHandler process.CurrentEpochHandler
+	writer              output.Writer
+	sinks               []output.Sink
+	outputFilePath      string
+	processedUntilEpoch uint32
+	// retryFromEpoch holds the first failed epoch of every statistic, the checkpoints saved after it hold the partial
+	// values of the failed epoch, so the next poll processes again all the epochs from it
+	retryFromEpoch map[string]uint32
+}
+
+func startDaemon(ctx *cli.Context) error {
+	cfg, err := loadMainConfig(ctx.GlobalString(configurationFile.Name))
+	if err != nil {
+		return err
+	}
+	applyConfigFlags(ctx, cfg)
+	if cfg.DaemonConfig.PollIntervalInSeconds <= 0 {
+		return fmt.Errorf("invalid daemon poll interval %d", cfg.DaemonConfig.PollIntervalInSeconds)
+	}
+
+	outputFormatV := ctx.GlobalString(outputFormat.Name)
+	outputFileV := ctx.GlobalString(outputFile.Name)
+	if !ctx.GlobalIsSet(outputFile.Name) {
+		outputFileV = "output." + outputFormatV
+	}
+
+	// every closed epoch is merged in the existing output files
+	writer, err := output.NewWriter(outputFormatV, true)
+	if err != nil {
+		return err
+	}
+
+	runCtx, cancel := contextWithShutdown()
+	defer cancel()
+
+	exporter, err := createMetricsExporter(cfg)
+	if err != nil {
+		return err
+	}
+
+	var metricsHandler statistics.MetricsHandler
+	if exporter != nil {
+		metricsHandler = exporter
+		metricsServer := startMetricsServer(cfg.MetricsConfig.ListenAddress, exporter)
+		defer func() {
+			_ = metricsServer.Close()
+		}()
+	}
+
+	db, err := statistics.OpenDatabase(cfg)
+	if err != nil {
+		return err
+	}
+	defer closeDatabase(db)
+
+	statsHandler, err := statistics.CreateStatsHandler(runCtx, cfg, db, metricsHandler)
+	if err != nil {
+		return err
+	}
+
+	sinks, err := statistics.CreateSinks(cfg, db)
+	if err != nil {
+		return err
+	}
+
+	currentEpochHandler, err := statistics.CreateCurrentEpochHandler(cfg)
+	if err != nil {
+		return err
+	}
+
+	follower := &epochsFollower{
+		statsHandler:        statsHandler,
+		currentEpochHandler: currentEpochHandler,
+		writer:              writer,
+		sinks:               sinks,
+		outputFilePath:      outputFileV,
+		retryFromEpoch:      map[string]uint32{},
+	}
+
+	ticker := time.NewTicker(time.Duration(cfg.DaemonConfig.PollIntervalInSeconds) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		err = follower.processClosedEpochs(runCtx)
+		if err != nil && runCtx.Err() == nil {
+			log.Printf("cannot process the closed epochs, error %s", err.Error())
+		}
+
+		select {
+		case <-ticker.C:
+		case <-runCtx.Done():
+			log.Printf("closing the daemon")
+			return nil
+		}
+	}
+}
+
+// processClosedEpochs generates the statistics of the epochs closed since the last call. The processors continue
+// from their checkpoints, so only the new epochs are processed, together with the epochs from the first failed one
+func (ef *epochsFollower) processClosedEpochs(ctx context.Context) error {
+	currentEpoch, err := ef.currentEpochHandler.CurrentEpoch(ctx)
+	if err != nil {
+		return err
+	}
+	if currentEpoch <= ef.processedUntilEpoch && len(ef.retryFromEpoch) == 0 {
+		return nil
+	}
+
+	log.Printf("processing the epochs closed before epoch %d", currentEpoch)
+	for _, statsOption := range statsInDependencyOrder {
+		startEpoch, resume := uint32(0), true
+		retryEpoch, shouldRetry := ef.retryFromEpoch[statsOption]
+		if shouldRetry {
+			// without resume the processors continue from the closest checkpoint before the failed epoch
+			log.Printf("processing again the %s epochs from the failed epoch %d", statsOption, retryEpoch)
+			startEpoch, resume = retryEpoch, false
+		}
+
+		failed, errGenerate := generateStatistics(
+			ctx,
+			ef.statsHandler,
+			ef.writer,
+			ef.sinks,
+			statsOption,
+			startEpoch,
+			currentEpoch,
+			resume,
+			outputFileForStats(ef.outputFilePath, statsOption),
+		)
+		if errGenerate != nil {
+			return errGenerate
+		}
+
+		logFailedEpochs(statsOption, failed)
+		if len(failed) > 0 {
+			ef.retryFromEpoch[statsOption] = failed[0].epoch
+		} else {
+			delete(ef.retryFromEpoch, statsOption)
+		}
+	}
+
+	ef.processedUntilEpoch = currentEpoch
+
+	return nil
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"testing"
+
+	"github.com/ElrondNetwork/statistics-go/data"
+	"github.com/ElrondNetwork/statistics-go/output"
+)
+
+// statsHandlerStub records the processed stake epochs and fails the provided stake epochs the first time they are
+// processed
+type statsHandlerStub struct {
+	failOnce  map[uint32]bool
+	stakeRuns []string
+}
+
+func (shs *statsHandlerStub) ProcessAllAccounts(_ context.Context, _, _ uint32, _ bool) ([]*data.StatisticsAddressesBalanceEpoch, error) {
+	return nil, nil
+}
+
+func (shs *statsHandlerStub) ProcessAllTransactions(_ context.Context, _, _ uint32, _ bool) ([]*data.StatisticsEpoch, error) {
+	return nil, nil
+}
+
+func (shs *statsHandlerStub) ProcessStakeInfo(_ context.Context, startEpoch, endEpoch uint32, resume bool) ([]*data.StakeInfoEpoch, error) {
+	shs.stakeRuns = append(shs.stakeRuns, fmt.Sprintf("%d-%d resume %v", startEpoch, endEpoch, resume))
+
+	records := make([]*data.StakeInfoEpoch, 0)
+	for epoch := startEpoch; epoch < endEpoch; epoch++ {
+		record := &data.StakeInfoEpoch{Epoch: epoch, Status: data.EpochStatusOK}
+		if shs.failOnce[epoch] {
+			shs.failOnce[epoch] = false
+			record.Status, record.Error = data.EpochStatusFailed, "scroll failed"
+		}
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+// currentEpochStub returns the epochs of the provided list on every call
+type currentEpochStub struct {
+	epochs []uint32
+	calls  int
+}
+
+func (ces *currentEpochStub) CurrentEpoch(_ context.Context) (uint32, error) {
+	epoch := ces.epochs[ces.calls]
+	ces.calls++
+
+	return epoch, nil
+}
+
+func TestEpochsFollower_ProcessesAgainFromTheFailedEpoch(t *testing.T) {
+	writer, _ := output.NewWriter(output.FormatJSON, true)
+	statsHandler := &statsHandlerStub{failOnce: map[uint32]bool{3: true}}
+	follower := &epochsFollower{
+		statsHandler:        statsHandler,
+		currentEpochHandler: &currentEpochStub{epochs: []uint32{5, 5, 5}},
+		writer:              writer,
+		outputFilePath:      path.Join(t.TempDir(), "output.json"),
+		retryFromEpoch:      map[string]uint32{},
+	}
+
+	for poll := 0; poll < 3; poll++ {
+		err := follower.processClosedEpochs(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+	}
+
+	// the third poll has neither new epochs nor failed ones
+	expectedRuns := []string{"0-5 resume true", "3-5 resume false"}
+	if fmt.Sprint(statsHandler.stakeRuns) != fmt.Sprint(expectedRuns) {
+		t.Fatalf("expected the stake runs %v, got %v", expectedRuns, statsHandler.stakeRuns)
+	}
+	if len(follower.retryFromEpoch) != 0 {
+		t.Fatalf("expected no epochs to retry, got %v", follower.retryFromEpoch)
+	}
+}
@@ -1,13 +1,22 @@
 package main
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
-	"io/ioutil"
 	"log"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/ElrondNetwork/elrond-go/core"
 	"github.com/ElrondNetwork/statistics-go/config"
+	"github.com/ElrondNetwork/statistics-go/data"
+	"github.com/ElrondNetwork/statistics-go/output"
 	"github.com/ElrondNetwork/statistics-go/statistics"
 	"github.com/urfave/cli"
 )
@@ -16,8 +25,16 @@ const (
 	optionTxs      = "transactions"
 	optionAccounts = "accounts"
 	optionStake    = "stake"
+	optionAll      = "all"
+
+	// flushTimeout bounds the publishing of the completed epochs after an interruption
+	flushTimeout = 30 * time.Second
 )
 
+// statsInDependencyOrder lists the statistics in the order they have to be generated, the accounts statistics
+// use the staked balances computed by the stake statistics
+var statsInDependencyOrder = []string{optionStake, optionAccounts, optionTxs}
+
 var (
 	// configurationFile defines a flag for the path to the main toml configuration file
 	configurationFile = cli.StringFlag{
@@ -27,24 +44,67 @@ var (
 	}
 	genesisFolder = cli.StringFlag{
 		Name:  "path-genesis-folder",
-		Usage: "The path to the folder with the genesis files",
-		Value: "../genesis",
+		Usage: "The path to the folder with the genesis files, overrides GenesisFolder from the configuration file",
+		Value: "",
+	}
+	stakeBalancesFolder = cli.StringFlag{
+		Name:  "path-stake-balances-folder",
+		Usage: "The path to the folder with the staked balances of every epoch, overrides StakeBalancesFolder from the configuration file",
+		Value: "",
+	}
+	stateFolder = cli.StringFlag{
+		Name:  "path-state-folder",
+		Usage: "The path to the folder with the processors checkpoints, overrides StateConfig.Folder from the configuration file",
+		Value: "",
+	}
+	startEpoch = cli.IntFlag{
+		Name:  "start-epoch",
+		Usage: "The first epoch for which statistics are generated",
+		Value: 0,
 	}
 	endEpoch = cli.IntFlag{
 		Name:  "end-epoch",
 		Usage: "The epoch until statistics are generated",
 		Value: 0,
 	}
+	epochsRange = cli.StringFlag{
+		Name:  "epochs",
+		Usage: "The inclusive range of epochs for which statistics are generated (e.g. 120-180), replaces start-epoch and end-epoch",
+		Value: "",
+	}
 	generateStatsOptions = cli.StringFlag{
 		Name:  "stats",
-		Usage: "Will generate statistics about transactions, accounts or stake",
+		Usage: "Will generate statistics about transactions, accounts or stake. Can be a comma separated list or all",
 		Value: "accounts",
 	}
 	outputFile = cli.StringFlag{
 		Name:  "output-file",
-		Usage: "The output file with statistics",
+		Usage: "The output file with statistics. When more statistics are generated, the name of every statistic is appended to the file name",
 		Value: "output.json",
 	}
+	outputFormat = cli.StringFlag{
+		Name:  "format",
+		Usage: "The format of the output files: json, ndjson, csv or parquet",
+		Value: output.FormatJSON,
+	}
+	resume = cli.BoolFlag{
+		Name:  "resume",
+		Usage: "Will continue from the last saved checkpoint and merge the new epochs in the existing output file",
+	}
+	workers = cli.IntFlag{
+		Name:  "workers",
+		Usage: "The number of epochs of transactions processed concurrently, overrides StatisticsConfig.TransactionsWorkers from the configuration file",
+		Value: 0,
+	}
+	countingMode = cli.StringFlag{
+		Name:  "counting-mode",
+		Usage: "How the transactions are counted: scan, aggregation or compare, overrides StatisticsConfig.TransactionsCountingMode from the configuration file",
+		Value: "",
+	}
+	strictMode = cli.BoolFlag{
+		Name:  "strict",
+		Usage: "Will stop at the first epoch that cannot be processed, overrides StatisticsConfig.Strict from the configuration file",
+	}
 )
 
 func main() {
@@ -56,9 +116,18 @@ func main() {
 	app.Flags = []cli.Flag{
 		configurationFile,
 		genesisFolder,
+		stakeBalancesFolder,
+		stateFolder,
+		startEpoch,
 		endEpoch,
+		epochsRange,
 		generateStatsOptions,
 		outputFile,
+		outputFormat,
+		resume,
+		workers,
+		countingMode,
+		strictMode,
 	}
 	app.Authors = []cli.Author{
 		{
@@ -68,6 +137,10 @@ func main() {
 	}
 
 	app.Action = startStatistics
+	app.Commands = []cli.Command{
+		serveCommand,
+		daemonCommand,
+	}
 
 	err := app.Run(os.Args)
 	if err != nil {
@@ -77,40 +150,318 @@ func main() {
 
 func startStatistics(ctx *cli.Context) error {
 	configurationFileName := ctx.GlobalString(configurationFile.Name)
-	pathGenesisFile := ctx.GlobalString(genesisFolder.Name)
-	statsOption := ctx.GlobalString(generateStatsOptions.Name)
-	endEpochV := ctx.GlobalInt(endEpoch.Name)
+	statsOptions, err := parseStatsOptions(ctx.GlobalString(generateStatsOptions.Name))
+	if err != nil {
+		return err
+	}
 	outputFileV := ctx.GlobalString(outputFile.Name)
+	outputFormatV := ctx.GlobalString(outputFormat.Name)
+	if !ctx.GlobalIsSet(outputFile.Name) {
+		outputFileV = "output." + outputFormatV
+	}
+	resumeV := ctx.GlobalBool(resume.Name)
+
+	startEpochV, endEpochV, err := getEpochsInterval(ctx)
+	if err != nil {
+		return err
+	}
+	if resumeV && startEpochV != 0 {
+		return fmt.Errorf("the %s flag cannot be used together with a start epoch", resume.Name)
+	}
+
+	writer, err := output.NewWriter(outputFormatV, resumeV || startEpochV > 0)
+	if err != nil {
+		return err
+	}
 
 	generalConfig, err := loadMainConfig(configurationFileName)
 	if err != nil {
 		return err
 	}
+	applyConfigFlags(ctx, generalConfig)
+
+	runCtx, cancel := contextWithShutdown()
+	defer cancel()
+
+	db, err := statistics.OpenDatabase(generalConfig)
+	if err != nil {
+		return err
+	}
+	defer closeDatabase(db)
+
+	statsHandler, err := statistics.CreateStatsHandler(runCtx, generalConfig, db, nil)
+	if err != nil {
+		return err
+	}
 
-	statsHandler, err := statistics.CreateStatsHandler(generalConfig, pathGenesisFile)
+	sinks, err := statistics.CreateSinks(generalConfig, db)
 	if err != nil {
 		return err
 	}
 
-	var bytes []byte
+	numFailed := 0
+	for _, statsOption := range statsOptions {
+		statsOutputFile := outputFileV
+		if len(statsOptions) > 1 {
+			statsOutputFile = outputFileForStats(outputFileV, statsOption)
+		}
+
+		failed, errGenerate := generateStatistics(runCtx, statsHandler, writer, sinks, statsOption, startEpochV, endEpochV, resumeV, statsOutputFile)
+		if runCtx.Err() != nil {
+			return fmt.Errorf("the processing was interrupted, the completed epochs were saved and can be continued with --%s", resume.Name)
+		}
+		if errGenerate != nil {
+			return errGenerate
+		}
+
+		logFailedEpochs(statsOption, failed)
+		numFailed += len(failed)
+	}
+	if numFailed > 0 {
+		return fmt.Errorf("%d epochs failed and were saved with the %s status", numFailed, data.EpochStatusFailed)
+	}
+
+	return nil
+}
+
+// generateStatistics processes, writes and publishes a statistic and returns the failed epochs. When the processing
+// is interrupted or stops on an error, the epochs completed until then are still written and published, since their
+// state was already saved
+func generateStatistics(
+	ctx context.Context,
+	statsHandler statistics.StatsHandler,
+	writer output.Writer,
+	sinks []output.Sink,
+	statsOption string,
+	startEpochV uint32,
+	endEpochV uint32,
+	resumeV bool,
+	outputFilePath string,
+) ([]epochFailure, error) {
+	var records interface{}
+	var errProcess error
 	switch statsOption {
 	case optionAccounts:
-		bytes, err = statsHandler.ProcessAllAccounts(uint32(endEpochV))
+		records, errProcess = statsHandler.ProcessAllAccounts(ctx, startEpochV, endEpochV, resumeV)
 	case optionStake:
-		bytes, err = statsHandler.ProcessStakeInfo(uint32(endEpochV))
+		records, errProcess = statsHandler.ProcessStakeInfo(ctx, startEpochV, endEpochV, resumeV)
 	case optionTxs:
-		bytes, err = statsHandler.ProcessAllTransactions(uint32(endEpochV))
+		records, errProcess = statsHandler.ProcessAllTransactions(ctx, startEpochV, endEpochV, resumeV)
+	}
+	if errProcess != nil && numRecords(records) == 0 {
+		return nil, errProcess
+	}
+
+	publishCtx := ctx
+	if ctx.Err() != nil {
+		var cancel context.CancelFunc
+		publishCtx, cancel = context.WithTimeout(context.Background(), flushTimeout)
+		defer cancel()
+	}
+
+	err := writer.Write(outputFilePath, records)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, sink := range sinks {
+		err = sink.Publish(publishCtx, records)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return failedEpochs(records), errProcess
+}
+
+func numRecords(records interface{}) int {
+	switch typedRecords := records.(type) {
+	case []*data.StatisticsEpoch:
+		return len(typedRecords)
+	case []*data.StatisticsAddressesBalanceEpoch:
+		return len(typedRecords)
+	case []*data.StakeInfoEpoch:
+		return len(typedRecords)
 	default:
-		return fmt.Errorf("please provide a valid option: %s, %s, %s", optionAccounts, optionStake, optionTxs)
+		return 0
+	}
+}
+
+// epochFailure is an epoch whose record was saved with the failed status
+type epochFailure struct {
+	epoch        uint32
+	errorMessage string
+}
+
+func failedEpochs(records interface{}) []epochFailure {
+	failed := make([]epochFailure, 0)
+	addIfFailed := func(epoch uint32, status string, errorMessage string) {
+		if status == data.EpochStatusFailed {
+			failed = append(failed, epochFailure{epoch: epoch, errorMessage: errorMessage})
+		}
+	}
+
+	switch typedRecords := records.(type) {
+	case []*data.StatisticsEpoch:
+		for _, record := range typedRecords {
+			addIfFailed(record.Epoch, record.Status, record.Error)
+		}
+	case []*data.StatisticsAddressesBalanceEpoch:
+		for _, record := range typedRecords {
+			addIfFailed(record.Epoch, record.Status, record.Error)
+		}
+	case []*data.StakeInfoEpoch:
+		for _, record := range typedRecords {
+			addIfFailed(record.Epoch, record.Status, record.Error)
+		}
+	}
+
+	return failed
+}
+
+func logFailedEpochs(statsOption string, failed []epochFailure) {
+	if len(failed) == 0 {
+		return
+	}
+
+	log.Printf("%d %s epochs failed:", len(failed), statsOption)
+	for _, failure := range failed {
+		log.Printf("    epoch %d: %s", failure.epoch, failure.errorMessage)
+	}
+}
+
+// closeDatabase closes the sqlite database opened for the command, when it is enabled
+func closeDatabase(db *sql.DB) {
+	if db == nil {
+		return
 	}
 
+	err := db.Close()
 	if err != nil {
-		return err
+		log.Printf("cannot close the sqlite database, error %s", err.Error())
 	}
+}
 
-	_ = ioutil.WriteFile(outputFileV, bytes, 0644)
+// contextWithShutdown returns a context that is canceled on SIGINT or SIGTERM
+func contextWithShutdown() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
 
-	return nil
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		select {
+		case <-sigs:
+			log.Printf("interrupt received, stopping after saving the completed epochs")
+			cancel()
+		case <-ctx.Done():
+		}
+		signal.Stop(sigs)
+	}()
+
+	return ctx, cancel
+}
+
+// parseStatsOptions returns the requested statistics in the order they have to be generated
+func parseStatsOptions(statsOptionsStr string) ([]string, error) {
+	requested := make(map[string]struct{})
+	for _, option := range strings.Split(statsOptionsStr, ",") {
+		option = strings.TrimSpace(option)
+		switch option {
+		case optionAll:
+			for _, stats := range statsInDependencyOrder {
+				requested[stats] = struct{}{}
+			}
+		case optionAccounts, optionStake, optionTxs:
+			requested[option] = struct{}{}
+		default:
+			return nil, fmt.Errorf("please provide a valid option: %s, %s, %s, %s or a comma separated list",
+				optionAccounts, optionStake, optionTxs, optionAll)
+		}
+	}
+
+	statsOptions := make([]string, 0, len(requested))
+	for _, stats := range statsInDependencyOrder {
+		if _, ok := requested[stats]; ok {
+			statsOptions = append(statsOptions, stats)
+		}
+	}
+
+	return statsOptions, nil
+}
+
+// outputFileForStats returns the output file of a statistic when more statistics are generated, output.json becomes output-stake.json
+func outputFileForStats(outputFilePath string, statsOption string) string {
+	extension := filepath.Ext(outputFilePath)
+
+	return fmt.Sprintf("%s-%s%s", strings.TrimSuffix(outputFilePath, extension), statsOption, extension)
+}
+
+// getEpochsInterval returns the first epoch and the epoch until statistics are generated (exclusive)
+func getEpochsInterval(ctx *cli.Context) (uint32, uint32, error) {
+	epochsRangeV := ctx.GlobalString(epochsRange.Name)
+	if epochsRangeV == "" {
+		startEpochV := ctx.GlobalInt(startEpoch.Name)
+		endEpochV := ctx.GlobalInt(endEpoch.Name)
+		if startEpochV < 0 || endEpochV < 0 {
+			return 0, 0, fmt.Errorf("epochs cannot be negative")
+		}
+		if startEpochV > endEpochV {
+			return 0, 0, fmt.Errorf("start epoch %d is greater than end epoch %d", startEpochV, endEpochV)
+		}
+
+		return uint32(startEpochV), uint32(endEpochV), nil
+	}
+
+	if ctx.GlobalIsSet(startEpoch.Name) || ctx.GlobalIsSet(endEpoch.Name) {
+		return 0, 0, fmt.Errorf("the %s flag cannot be used together with %s or %s", epochsRange.Name, startEpoch.Name, endEpoch.Name)
+	}
+
+	return parseEpochsRange(epochsRangeV)
+}
+
+// parseEpochsRange parses an inclusive range like 120-180 and returns the first and the last epoch plus one
+func parseEpochsRange(epochsRangeStr string) (uint32, uint32, error) {
+	limits := strings.Split(epochsRangeStr, "-")
+	if len(limits) != 2 {
+		return 0, 0, fmt.Errorf("invalid epochs range %s, expected format start-end", epochsRangeStr)
+	}
+
+	first, err := strconv.ParseUint(strings.TrimSpace(limits[0]), 10, 32)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid start of epochs range %s: %w", epochsRangeStr, err)
+	}
+	last, err := strconv.ParseUint(strings.TrimSpace(limits[1]), 10, 32)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid end of epochs range %s: %w", epochsRangeStr, err)
+	}
+	if first > last {
+		return 0, 0, fmt.Errorf("invalid epochs range %s, start is greater than end", epochsRangeStr)
+	}
+
+	return uint32(first), uint32(last) + 1, nil
+}
+
+// applyConfigFlags overrides the folders, the workers, the counting mode and the strict mode from the configuration file with the ones
+// provided as flags
+func applyConfigFlags(ctx *cli.Context, cfg *config.Config) {
+	if ctx.GlobalIsSet(genesisFolder.Name) {
+		cfg.GeneralConfig.GenesisFolder = ctx.GlobalString(genesisFolder.Name)
+	}
+	if ctx.GlobalIsSet(stakeBalancesFolder.Name) {
+		cfg.GeneralConfig.StakeBalancesFolder = ctx.GlobalString(stakeBalancesFolder.Name)
+	}
+	if ctx.GlobalIsSet(stateFolder.Name) {
+		cfg.StateConfig.Folder = ctx.GlobalString(stateFolder.Name)
+	}
+	if ctx.GlobalIsSet(workers.Name) {
+		cfg.StatisticsConfig.TransactionsWorkers = ctx.GlobalInt(workers.Name)
+	}
+	if ctx.GlobalIsSet(countingMode.Name) {
+		cfg.StatisticsConfig.TransactionsCountingMode = ctx.GlobalString(countingMode.Name)
+	}
+	if ctx.GlobalBool(strictMode.Name) {
+		cfg.StatisticsConfig.Strict = true
+	}
 }
 
 func loadMainConfig(filepath string) (*config.Config, error) {
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"testing"
+
+	"github.com/urfave/cli"
+)
+
+func TestParseEpochsRange(t *testing.T) {
+	tests := []struct {
+		epochsRange   string
+		expectedStart uint32
+		expectedEnd   uint32
+		expectedError bool
+	}{
+		{epochsRange: "120-180", expectedStart: 120, expectedEnd: 181},
+		{epochsRange: " 5 - 5 ", expectedStart: 5, expectedEnd: 6},
+		{epochsRange: "0-0", expectedStart: 0, expectedEnd: 1},
+		{epochsRange: "0", expectedError: true},
+		{epochsRange: "180-120", expectedError: true},
+		{epochsRange: "1-2-3", expectedError: true},
+		{epochsRange: "a-5", expectedError: true},
+		{epochsRange: "5-", expectedError: true},
+		{epochsRange: "-1-5", expectedError: true},
+	}
+
+	for _, test := range tests {
+		start, end, err := parseEpochsRange(test.epochsRange)
+		if test.expectedError {
+			if err == nil {
+				t.Fatalf("expected error for range %q, got %d-%d", test.epochsRange, start, end)
+			}
+			continue
+		}
+		if err != nil || start != test.expectedStart || end != test.expectedEnd {
+			t.Fatalf("expected %d-%d for range %q, got %d-%d, error %v", test.expectedStart, test.expectedEnd, test.epochsRange, start, end, err)
+		}
+	}
+}
+
+func newEpochsContext(t *testing.T, args ...string) *cli.Context {
+	set := flag.NewFlagSet("statistics", flag.ContinueOnError)
+	startEpoch.Apply(set)
+	endEpoch.Apply(set)
+	epochsRange.Apply(set)
+	err := set.Parse(args)
+	if err != nil {
+		t.Fatalf("cannot parse the flags %v, error %v", args, err)
+	}
+
+	return cli.NewContext(nil, set, nil)
+}
+
+func TestGetEpochsInterval(t *testing.T) {
+	tests := []struct {
+		args          []string
+		expectedStart uint32
+		expectedEnd   uint32
+		expectedError bool
+	}{
+		{args: nil, expectedStart: 0, expectedEnd: 0},
+		{args: []string{"--start-epoch", "10", "--end-epoch", "20"}, expectedStart: 10, expectedEnd: 20},
+		{args: []string{"--start-epoch", "20", "--end-epoch", "10"}, expectedError: true},
+		{args: []string{"--start-epoch", "-1", "--end-epoch", "10"}, expectedError: true},
+		{args: []string{"--epochs", "10-20"}, expectedStart: 10, expectedEnd: 21},
+		{args: []string{"--epochs", "0-0"}, expectedStart: 0, expectedEnd: 1},
+		{args: []string{"--epochs", "20-10"}, expectedError: true},
+		{args: []string{"--epochs", "0"}, expectedError: true},
+		{args: []string{"--epochs", "10-20", "--start-epoch", "10"}, expectedError: true},
+		{args: []string{"--epochs", "10-20", "--end-epoch", "0"}, expectedError: true},
+	}
+
+	for _, test := range tests {
+		start, end, err := getEpochsInterval(newEpochsContext(t, test.args...))
+		if test.expectedError {
+			if err == nil {
+				t.Fatalf("expected error for the flags %v, got %d-%d", test.args, start, end)
+			}
+			continue
+		}
+		if err != nil || start != test.expectedStart || end != test.expectedEnd {
+			t.Fatalf("expected %d-%d for the flags %v, got %d-%d, error %v", test.expectedStart, test.expectedEnd, test.args, start, end, err)
+		}
+	}
+}
+
+func TestParseStatsOptions(t *testing.T) {
+	tests := []struct {
+		statsOptions  string
+		expected      []string
+		expectedError bool
+	}{
+		{statsOptions: "all", expected: []string{optionStake, optionAccounts, optionTxs}},
+		{statsOptions: "transactions,stake", expected: []string{optionStake, optionTxs}},
+		{statsOptions: " accounts , accounts ", expected: []string{optionAccounts}},
+		{statsOptions: "all,transactions", expected: []string{optionStake, optionAccounts, optionTxs}},
+		{statsOptions: "stake,all", expected: []string{optionStake, optionAccounts, optionTxs}},
+		{statsOptions: "balances", expectedError: true},
+		{statsOptions: "stake,balances", expectedError: true},
+		{statsOptions: "stake,", expectedError: true},
+		{statsOptions: "", expectedError: true},
+	}
+
+	for _, test := range tests {
+		statsOptions, err := parseStatsOptions(test.statsOptions)
+		if test.expectedError {
+			if err == nil {
+				t.Fatalf("expected error for %q, got %v", test.statsOptions, statsOptions)
+			}
+			continue
+		}
+		if err != nil || fmt.Sprint(statsOptions) != fmt.Sprint(test.expected) {
+			t.Fatalf("expected %v for %q, got %v, error %v", test.expected, test.statsOptions, statsOptions, err)
+		}
+	}
+}
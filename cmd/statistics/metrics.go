@@ -0,0 +1,55 @@
+package main
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/ElrondNetwork/statistics-go/api"
+	"github.com/ElrondNetwork/statistics-go/config"
+	"github.com/ElrondNetwork/statistics-go/metrics"
+	"github.com/ElrondNetwork/statistics-go/statistics"
+)
+
+const metricsPath = "/metrics"
+
+// metricsExporter collects the metrics and exposes them over http
+type metricsExporter interface {
+	statistics.MetricsHandler
+	Handler() http.Handler
+}
+
+// createMetricsExporter returns the prometheus metrics when they are enabled and nil otherwise
+func createMetricsExporter(cfg *config.Config) (metricsExporter, error) {
+	if !cfg.MetricsConfig.Enabled {
+		return nil, nil
+	}
+
+	return metrics.NewPrometheusMetrics()
+}
+
+// startMetricsServer serves the metrics on their own address, used by the daemon that has no http api
+func startMetricsServer(listenAddress string, exporter metricsExporter) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle(metricsPath, exporter.Handler())
+
+	metricsServer := &http.Server{
+		Addr:    listenAddress,
+		Handler: mux,
+	}
+
+	go func() {
+		err := metricsServer.ListenAndServe()
+		if err != nil && err != http.ErrServerClosed {
+			log.Printf("cannot serve the metrics, error %s", err.Error())
+		}
+	}()
+
+	return metricsServer
+}
+
+// publishLatestResults sets the metrics to the statistics of the latest epoch that did not fail from the served results
+func publishLatestResults(results api.ResultsHolder, exporter metricsExporter) {
+	statistics.PublishLatestTransactions(exporter, results.Transactions())
+	statistics.PublishLatestAccounts(exporter, results.Accounts())
+	statistics.PublishLatestStake(exporter, results.Stake())
+}
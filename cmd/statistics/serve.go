@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/ElrondNetwork/statistics-go/api"
+	"github.com/ElrondNetwork/statistics-go/output"
+	"github.com/ElrondNetwork/statistics-go/statistics"
+	"github.com/urfave/cli"
+)
+
+// serveCommand exposes over http the statistics generated by the runs with --stats all or by the daemon, in json format
+var serveCommand = cli.Command{
+	Name:   "serve",
+	Usage:  "Serves over http the statistics from the json output files, read again periodically",
+	Action: startServer,
+}
+
+type refresher interface {
+	api.ResultsHolder
+	Refresh() error
+}
+
+func startServer(ctx *cli.Context) error {
+	if ctx.GlobalString(outputFormat.Name) != output.FormatJSON {
+		return fmt.Errorf("the statistics can be served only from the %s output files", output.FormatJSON)
+	}
+
+	cfg, err := loadMainConfig(ctx.GlobalString(configurationFile.Name))
+	if err != nil {
+		return err
+	}
+	applyConfigFlags(ctx, cfg)
+
+	outputFileV := ctx.GlobalString(outputFile.Name)
+	results, err := api.NewFileResults(
+		outputFileForStats(outputFileV, optionTxs),
+		outputFileForStats(outputFileV, optionAccounts),
+		outputFileForStats(outputFileV, optionStake),
+	)
+	if err != nil {
+		return err
+	}
+
+	db, err := statistics.OpenDatabase(cfg)
+	if err != nil {
+		return err
+	}
+	defer closeDatabase(db)
+
+	stakeBalances, err := statistics.CreateStakeBalancesStorer(cfg, db)
+	if err != nil {
+		return err
+	}
+
+	server, err := api.NewServer(cfg.ServerConfig.ListenAddress, results, stakeBalances)
+	if err != nil {
+		return err
+	}
+
+	exporter, err := createMetricsExporter(cfg)
+	if err != nil {
+		return err
+	}
+	if exporter != nil {
+		publishLatestResults(results, exporter)
+		server.RegisterHandler(metricsPath, exporter.Handler())
+	}
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go refreshPeriodically(results, exporter, time.Duration(cfg.ServerConfig.RefreshIntervalInSeconds)*time.Second, stop)
+
+	go func() {
+		sigs := make(chan os.Signal, 1)
+		signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+		<-sigs
+
+		log.Printf("closing the statistics api")
+		errClose := server.Close()
+		if errClose != nil {
+			log.Printf("cannot close the statistics api, error %s", errClose.Error())
+		}
+	}()
+
+	return server.Start()
+}
+
+// refreshPeriodically reads again the results with the provided interval, a non positive interval disables it.
+// The metrics, when enabled, follow the refreshed results
+func refreshPeriodically(results refresher, exporter metricsExporter, interval time.Duration, stop chan struct{}) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			err := results.Refresh()
+			if err != nil {
+				log.Printf("cannot refresh the served statistics, error %s", err.Error())
+				continue
+			}
+			if exporter != nil {
+				publishLatestResults(results, exporter)
+			}
+		case <-stop:
+			return
+		}
+	}
+}
@@ -5,6 +5,17 @@ import "github.com/ElrondNetwork/elrond-go/config"
 // Config will hold the whole config file's data
 type Config struct {
 	GeneralConfig          GeneralConfig
+	IndicesConfig          IndicesConfig
+	StateConfig            StateConfig
+	StatisticsConfig       StatisticsConfig
+	AccountsExclusions     []AddressesExclusionConfig
+	ElasticSinkConfig      ElasticSinkConfig
+	SQLiteConfig           SQLiteConfig
+	ServerConfig           ServerConfig
+	DaemonConfig           DaemonConfig
+	MetricsConfig          MetricsConfig
+	ElasticRetryConfig     RetryConfig
+	APIRetryConfig         RetryConfig
 	AddressPubkeyConverter config.PubkeyConfig
 }
 
@@ -12,8 +23,102 @@ type Config struct {
 type GeneralConfig struct {
 	APIUrl                          string
 	ElasticDatabaseAddress          string
+	ElasticDatabaseAddresses        []string
+	ElasticCloudID                  string
 	Username                        string
 	Password                        string
+	ElasticAPIKey                   string
+	ElasticBearerToken              string
+	ElasticCACertFile               string
+	ElasticClientCertFile           string
+	ElasticClientKeyFile            string
+	ElasticTLSSkipVerify            bool
 	DelegationLegacyContractAddress string
 	StakingContractAddress          string
+	EpochBoundariesSource           string
+	GenesisFolder                   string
+	StakeBalancesFolder             string
+	ElasticRequestTimeoutInSeconds  int
+	APIRequestTimeoutInSeconds      int
+	ElasticMaxParallelSlices        int
+	ElasticPagination               string
+}
+
+// IndicesConfig will hold the elasticsearch indices read for every dataset. Every index can be an index name, an alias,
+// a pattern or a comma separated list of them, the prefix is added to all of them
+type IndicesConfig struct {
+	Prefix          string
+	Transactions    string
+	AccountsHistory string
+	Blocks          string
+}
+
+// StateConfig will hold the settings for the processors checkpoints
+type StateConfig struct {
+	Folder              string
+	CheckpointsToKeep   int
+	CheckpointsInterval int
+}
+
+// StatisticsConfig will hold the settings of the generated statistics
+type StatisticsConfig struct {
+	BalanceBuckets               []string
+	TopAddressesCount            int
+	ActiveAddressesFolder        string
+	TransactionsWorkers          int
+	TransactionsScrollSlices     int
+	TransactionsCountingMode     string
+	TransactionsComparisonReport string
+	Strict                       bool
+}
+
+// AddressesExclusionConfig will hold a set of addresses that are not counted in the balance statistics
+// starting with an epoch
+type AddressesExclusionConfig struct {
+	ActivationEpoch uint32
+	Addresses       []string
+	AddressPrefixes []string
+	AddressesFile   string
+}
+
+// ElasticSinkConfig will hold the settings for indexing the generated statistics in elasticsearch
+type ElasticSinkConfig struct {
+	Enabled           bool
+	TransactionsIndex string
+	AccountsIndex     string
+	StakeIndex        string
+}
+
+// SQLiteConfig will hold the settings of the embedded database where the generated statistics and the staked
+// balances are stored
+type SQLiteConfig struct {
+	Enabled      bool
+	DatabasePath string
+	StoreState   bool
+}
+
+// ServerConfig will hold the settings of the http api that serves the generated statistics
+type ServerConfig struct {
+	ListenAddress            string
+	RefreshIntervalInSeconds int
+}
+
+// DaemonConfig will hold the settings of the daemon that generates the statistics of every closed epoch
+type DaemonConfig struct {
+	CurrentEpochSource    string
+	PollIntervalInSeconds int
+}
+
+// MetricsConfig will hold the settings of the prometheus metrics exposed in the serve and daemon modes
+type MetricsConfig struct {
+	Enabled       bool
+	ListenAddress string
+}
+
+// RetryConfig will hold the retry policy of the requests of a client
+type RetryConfig struct {
+	MaxAttempts                  int
+	InitialBackoffInMilliseconds int
+	MaxBackoffInMilliseconds     int
+	RetryableStatusCodes         []int
 }
@@ -2,6 +2,7 @@ package data
 
 import (
 	"encoding/json"
+	"sort"
 	"time"
 
 	"github.com/ElrondNetwork/elastic-indexer-go/data"
@@ -18,34 +19,86 @@ type ScrollTransactionsResponse struct {
 	} `json:"hits"`
 }
 
+// TransactionsHistogramAggregation holds the number of transactions of every bucket of a date histogram
+type TransactionsHistogramAggregation struct {
+	Transactions struct {
+		Buckets []struct {
+			DocCount int `json:"doc_count"`
+		} `json:"buckets"`
+	} `json:"transactions"`
+}
+
+// AddressesAggregation holds a page of the composite aggregation of the transactions on an address field
+type AddressesAggregation struct {
+	Addresses struct {
+		AfterKey json.RawMessage  `json:"after_key"`
+		Buckets  []*AddressBucket `json:"buckets"`
+	} `json:"addresses"`
+}
+
+// AddressBucket holds the number of transactions of an address, and how many of them were not sent by the metachain
+type AddressBucket struct {
+	Key struct {
+		Address string `json:"address"`
+	} `json:"key"`
+	DocCount         int `json:"doc_count"`
+	NotFromMetachain struct {
+		DocCount int `json:"doc_count"`
+	} `json:"notFromMetachain"`
+}
+
 type StatisticsEpoch struct {
-	Epoch                       uint32         `json:"epoch"`
-	DailyTransactions           int            `json:"dailyTransactions"`
-	DailyContractCalls          int            `json:"dailyContractCalls"`
-	DailyActiveAccounts         int            `json:"dailyActiveAccounts"`
-	DailyActiveContractAccounts int            `json:"dailyActiveContractAccounts"`
-	DailyNewAddresses           int            `json:"dailyNewAddresses"`
-	DailyNewContractAddresses   int            `json:"dailyNewContractAddresses"`
-	TopActiveAddresses          map[string]int `json:"topActiveAccounts"`
-	TopActiveContracts          map[string]int `json:"topActiveContracts"`
+	Epoch                       uint32          `json:"epoch"`
+	DailyTransactions           int             `json:"dailyTransactions"`
+	DailyContractCalls          int             `json:"dailyContractCalls"`
+	DailyActiveAccounts         int             `json:"dailyActiveAccounts"`
+	DailyActiveContractAccounts int             `json:"dailyActiveContractAccounts"`
+	DailyNewAddresses           int             `json:"dailyNewAddresses"`
+	DailyNewContractAddresses   int             `json:"dailyNewContractAddresses"`
+	TopActiveAddresses          []*AddressCount `json:"topActiveAccounts"`
+	TopActiveContracts          []*AddressCount `json:"topActiveContracts"`
+	Status                      string          `json:"status"`
+	Error                       string          `json:"error,omitempty"`
 }
 
-func (se *StatisticsEpoch) SetInfoAboutDailyAccounts(dailyAccounts map[string]int) {
-	se.TopActiveAddresses = make(map[string]int)
+// AddressCount holds the number of transactions of an address in an epoch
+type AddressCount struct {
+	Address string `json:"address"`
+	Count   int    `json:"count"`
+}
 
+func (se *StatisticsEpoch) SetInfoAboutDailyAccounts(dailyAccounts map[string]int, topCount int) {
 	se.DailyActiveAccounts = len(dailyAccounts)
-	for key, value := range dailyAccounts {
-		se.TopActiveAddresses[key] = value
-	}
+	se.TopActiveAddresses = topAddresses(dailyAccounts, topCount)
 }
 
-func (se *StatisticsEpoch) SetInfoAboutDailyContracts(dailyContracts map[string]int) {
-	se.TopActiveContracts = make(map[string]int)
-
+func (se *StatisticsEpoch) SetInfoAboutDailyContracts(dailyContracts map[string]int, topCount int) {
 	se.DailyActiveContractAccounts = len(dailyContracts)
-	for key, value := range dailyContracts {
-		se.TopActiveContracts[key] = value
+	se.TopActiveContracts = topAddresses(dailyContracts, topCount)
+}
+
+// topAddresses returns the topCount addresses with the most transactions, sorted descending by count
+func topAddresses(counts map[string]int, topCount int) []*AddressCount {
+	sorted := make([]*AddressCount, 0, len(counts))
+	for address, count := range counts {
+		sorted = append(sorted, &AddressCount{
+			Address: address,
+			Count:   count,
+		})
 	}
+
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Count != sorted[j].Count {
+			return sorted[i].Count > sorted[j].Count
+		}
+		return sorted[i].Address < sorted[j].Address
+	})
+
+	if topCount < len(sorted) {
+		sorted = sorted[:topCount]
+	}
+
+	return sorted
 }
 
 type ScrollAccountsResponse struct {
@@ -59,15 +112,36 @@ type ScrollAccountsResponse struct {
 }
 
 type StatisticsAddressesBalanceEpoch struct {
-	Epoch                  uint32 `json:"epoch"`
-	TotalAddresses         int    `json:"totalAddresses"`
-	TotalContractAddresses int    `json:"totalContractAddresses"`
-	NonZero                int    `json:"nonZero"`
-	B01EGLD                int    `json:"b01EGLD"`
-	B1EGLD                 int    `json:"b1EGLD"`
-	B10EGLD                int    `json:"b10EGLD"`
-	B100EGLD               int    `json:"b100EGLD"`
-	B1kEGLD                int    `json:"b1KEGLD"`
+	Epoch                  uint32               `json:"epoch"`
+	TotalAddresses         int                  `json:"totalAddresses"`
+	TotalContractAddresses int                  `json:"totalContractAddresses"`
+	ExcludedAddresses      int                  `json:"excludedAddresses"`
+	NonZero                int                  `json:"nonZero"`
+	BalanceDistribution    []*BalanceBucket     `json:"balanceDistribution"`
+	WealthConcentration    *WealthConcentration `json:"wealthConcentration"`
+	Status                 string               `json:"status"`
+	Error                  string               `json:"error,omitempty"`
+}
+
+// WealthConcentration holds the distribution metrics of the balances of the non-zero addresses of an epoch.
+// Balances are denominated values, shares are fractions of the total balance
+type WealthConcentration struct {
+	TotalBalance        string  `json:"totalBalance"`
+	MedianBalance       string  `json:"medianBalance"`
+	P90Balance          string  `json:"p90Balance"`
+	P99Balance          string  `json:"p99Balance"`
+	P999Balance         string  `json:"p999Balance"`
+	GiniCoefficient     float64 `json:"giniCoefficient"`
+	NakamotoCoefficient int     `json:"nakamotoCoefficient"`
+	Top10Share          float64 `json:"top10Share"`
+	Top100Share         float64 `json:"top100Share"`
+	Top1000Share        float64 `json:"top1000Share"`
+}
+
+// BalanceBucket holds the number of addresses with a balance greater or equal than the threshold (in EGLD)
+type BalanceBucket struct {
+	Threshold string `json:"threshold"`
+	Count     int    `json:"count"`
 }
 
 type SearchResponse struct {
@@ -90,6 +164,8 @@ type StakeInfoEpoch struct {
 	TotalUniqueUsers     int    `json:"totalUniqueUsers"`
 	DelegationUsers      int    `json:"delegationUsers"`
 	Delegation           string `json:"delegation"`
+	Status               string `json:"status"`
+	Error                string `json:"error,omitempty"`
 }
 
 type ScrollTransactionsSCRS struct {
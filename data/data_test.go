@@ -0,0 +1,40 @@
+package data
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestTopAddresses(t *testing.T) {
+	counts := map[string]int{"erd1c": 5, "erd1a": 3, "erd1b": 5, "erd1d": 1}
+
+	tests := []struct {
+		name     string
+		counts   map[string]int
+		topCount int
+		expected string
+	}{
+		{name: "sorted by count", counts: map[string]int{"erd1a": 1, "erd1b": 7, "erd1c": 3}, topCount: 3, expected: "erd1b:7 erd1c:3 erd1a:1"},
+		{name: "ties broken on address", counts: counts, topCount: 4, expected: "erd1b:5 erd1c:5 erd1a:3 erd1d:1"},
+		{name: "truncated", counts: counts, topCount: 2, expected: "erd1b:5 erd1c:5"},
+		{name: "truncated inside a tie", counts: counts, topCount: 1, expected: "erd1b:5"},
+		{name: "no top addresses", counts: counts, topCount: 0, expected: ""},
+		{name: "fewer addresses than the top count", counts: counts, topCount: 10, expected: "erd1b:5 erd1c:5 erd1a:3 erd1d:1"},
+		{name: "no addresses", counts: map[string]int{}, topCount: 10, expected: ""},
+	}
+
+	for _, test := range tests {
+		top := topAddresses(test.counts, test.topCount)
+
+		result := ""
+		for idx, addressCount := range top {
+			if idx > 0 {
+				result += " "
+			}
+			result += fmt.Sprintf("%s:%d", addressCount.Address, addressCount.Count)
+		}
+		if result != test.expected {
+			t.Fatalf("%s: expected %q, got %q", test.name, test.expected, result)
+		}
+	}
+}
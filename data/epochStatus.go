@@ -0,0 +1,17 @@
+package data
+
+const (
+	// EpochStatusOK marks the record of an epoch that was processed without errors
+	EpochStatusOK = "ok"
+	// EpochStatusFailed marks the record of an epoch that could not be processed completely, its values are partial
+	EpochStatusFailed = "failed"
+)
+
+// EpochStatus returns the status and the error message of the record of an epoch processed with the provided error
+func EpochStatus(err error) (string, string) {
+	if err != nil {
+		return EpochStatusFailed, err.Error()
+	}
+
+	return EpochStatusOK, ""
+}
@@ -0,0 +1,14 @@
+package data
+
+import "errors"
+
+// ErrScrollExpired signals that a scroll expired or failed before all the documents were read, so the documents of
+// the whole time window have to be read again
+var ErrScrollExpired = errors.New("scroll cannot be continued")
+
+// ErrRetriesExhausted signals that a request failed after all the configured attempts
+var ErrRetriesExhausted = errors.New("retries exhausted")
+
+// ErrStateRestore signals that the state from before an epoch could not be restored for processing it again, so the
+// state of the processor is not consistent anymore
+var ErrStateRestore = errors.New("state cannot be restored")
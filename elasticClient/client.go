@@ -3,81 +3,270 @@ package elasticClient
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"net/http"
+	"sync"
 	"time"
 
+	"github.com/ElrondNetwork/statistics-go/data"
+	"github.com/ElrondNetwork/statistics-go/retry"
 	"github.com/elastic/go-elasticsearch/v7"
 	"github.com/elastic/go-elasticsearch/v7/esapi"
 	"github.com/tidwall/gjson"
 )
 
+const (
+	// PaginationScroll reads all the documents of a query with a scroll context
+	PaginationScroll = "scroll"
+	// PaginationPointInTime reads all the documents of a query with search_after over a point in time
+	PaginationPointInTime = "pit"
+)
+
+const (
+	// clearScrollTimeout bounds the request that clears a scroll, which is done even when the run was interrupted
+	clearScrollTimeout = 10 * time.Second
+
+	// keepAlive is how long a scroll or a point in time is kept between two pages
+	keepAlive = 5 * time.Minute
+
+	// searchTimeout bounds the search requests on the server side
+	searchTimeout = 5 * time.Minute
+
+	pageSize = 9000
+)
+
 type elasticClient struct {
-	client  *elasticsearch.Client
-	counter uint64
+	client         *elasticsearch.Client
+	requestTimeout time.Duration
+	retryHandler   RetryHandler
+	sliceTokens    chan struct{}
+	pagination     string
 }
 
-func NewElasticClient(cfg elasticsearch.Config) (*elasticClient, error) {
+// NewElasticClient will create a new instance of elasticClient. Every request is bounded by the provided timeout,
+// 0 means no timeout, and the failed requests are retried by the provided retry handler. At most maxParallelSlices
+// slices of the sliced scrolls are read at the same time. The pagination selects how all the documents of a query
+// are read, PaginationScroll or PaginationPointInTime
+func NewElasticClient(
+	cfg elasticsearch.Config,
+	requestTimeout time.Duration,
+	retryHandler RetryHandler,
+	maxParallelSlices int,
+	pagination string,
+) (*elasticClient, error) {
+	if retryHandler == nil {
+		return nil, errors.New("nil retry handler")
+	}
+	if maxParallelSlices < 1 {
+		return nil, fmt.Errorf("invalid number of parallel slices: %d", maxParallelSlices)
+	}
+	if pagination != PaginationScroll && pagination != PaginationPointInTime {
+		return nil, fmt.Errorf("unknown pagination %s", pagination)
+	}
+
 	client, err := elasticsearch.NewClient(cfg)
 	if err != nil {
 		return nil, fmt.Errorf("cannot create database reader %w", err)
 	}
 
 	return &elasticClient{
-		client:  client,
-		counter: 0,
+		client:         client,
+		requestTimeout: requestTimeout,
+		retryHandler:   retryHandler,
+		sliceTokens:    make(chan struct{}, maxParallelSlices),
+		pagination:     pagination,
 	}, nil
 }
 
+func (ec *elasticClient) requestContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if ec.requestTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeout(ctx, ec.requestTimeout)
+}
+
+// DoScrollRequestAllDocuments will pass every page of the documents that match the query to the provided handler,
+// using the configured pagination. When the documents cannot be read until the end, data.ErrScrollExpired is
+// returned and the pages already passed to the handler have to be discarded
 func (ec *elasticClient) DoScrollRequestAllDocuments(
+	ctx context.Context,
 	query *bytes.Buffer,
 	index string,
 	handlerFunc func(responseBytes []byte) error,
 ) error {
-	ec.counter++
-	res, err := ec.client.Search(
-		ec.client.Search.WithSize(9000),
-		ec.client.Search.WithScroll(5*time.Minute+time.Duration(ec.counter)*time.Millisecond),
-		ec.client.Search.WithContext(context.Background()),
-		ec.client.Search.WithIndex(index),
-		ec.client.Search.WithBody(query),
-	)
-	if err != nil {
-		return err
+	if ec.pagination == PaginationPointInTime {
+		return ec.doPointInTimeRequestAllDocuments(ctx, query, index, handlerFunc)
 	}
 
-	bodyBytes, err := getBytesFromResponse(res)
+	return ec.doScrollRequestAllDocuments(ctx, query, index, handlerFunc)
+}
+
+func (ec *elasticClient) doScrollRequestAllDocuments(
+	ctx context.Context,
+	query *bytes.Buffer,
+	index string,
+	handlerFunc func(responseBytes []byte) error,
+) error {
+	bodyBytes, err := ec.startScroll(ctx, query, index)
 	if err != nil {
 		return err
 	}
 
+	scrollID := gjson.Get(string(bodyBytes), "_scroll_id").String()
+	if scrollID != "" {
+		defer func() {
+			errClear := ec.clearScroll(scrollID)
+			if errClear != nil {
+				log.Print("cannot clear scroll ", errClear)
+			}
+		}()
+	}
+
 	err = handlerFunc(bodyBytes)
 	if err != nil {
 		return err
 	}
 
-	scrollID := gjson.Get(string(bodyBytes), "_scroll_id")
-	return ec.iterateScroll(scrollID.String(), handlerFunc)
+	return ec.iterateScroll(ctx, scrollID, handlerFunc)
+}
+
+// DoSlicedScrollRequestAllDocuments splits the scroll of the documents that match the query in numSlices slices
+// that are read concurrently, at most maxParallelSlices at a time across all the requests of the client. The pages
+// are passed to the handler one at a time, in no particular order. The first slice that fails stops the others.
+// Every slice uses the configured pagination, slicing a point in time needs elasticsearch 7.14 or newer
+func (ec *elasticClient) DoSlicedScrollRequestAllDocuments(
+	ctx context.Context,
+	query *bytes.Buffer,
+	index string,
+	numSlices int,
+	handlerFunc func(responseBytes []byte) error,
+) error {
+	if numSlices <= 1 {
+		return ec.DoScrollRequestAllDocuments(ctx, query, index, handlerFunc)
+	}
+
+	sliceQueries := make([]*bytes.Buffer, 0, numSlices)
+	for sliceID := 0; sliceID < numSlices; sliceID++ {
+		sliceQuery, err := withSlice(query.Bytes(), sliceID, numSlices)
+		if err != nil {
+			return err
+		}
+		sliceQueries = append(sliceQueries, sliceQuery)
+	}
+
+	slicesCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	mutHandler := sync.Mutex{}
+	serialHandler := func(responseBytes []byte) error {
+		mutHandler.Lock()
+		defer mutHandler.Unlock()
+
+		return handlerFunc(responseBytes)
+	}
+
+	// the failing slice sends its error before stopping the others, so the first error is the cause
+	errs := make(chan error, numSlices)
+	wg := &sync.WaitGroup{}
+	for _, sliceQuery := range sliceQueries {
+		wg.Add(1)
+		go func(sliceQuery *bytes.Buffer) {
+			defer wg.Done()
+
+			select {
+			case ec.sliceTokens <- struct{}{}:
+			case <-slicesCtx.Done():
+				return
+			}
+			defer func() {
+				<-ec.sliceTokens
+			}()
+
+			err := ec.DoScrollRequestAllDocuments(slicesCtx, sliceQuery, index, serialHandler)
+			if err != nil {
+				errs <- err
+				cancel()
+			}
+		}(sliceQuery)
+	}
+	wg.Wait()
+	close(errs)
+
+	err, hasErr := <-errs
+	if !hasErr {
+		return ctx.Err()
+	}
+
+	return err
+}
+
+// withSlice adds to the query the slice with the provided id out of max slices
+func withSlice(queryBytes []byte, sliceID int, max int) (*bytes.Buffer, error) {
+	query := make(map[string]interface{})
+	err := json.Unmarshal(queryBytes, &query)
+	if err != nil {
+		return nil, fmt.Errorf("cannot add the slice to the query: %w", err)
+	}
+
+	query["slice"] = map[string]interface{}{
+		"id":  sliceID,
+		"max": max,
+	}
+
+	sliceQueryBytes, err := json.Marshal(query)
+	if err != nil {
+		return nil, err
+	}
+
+	return bytes.NewBuffer(sliceQueryBytes), nil
+}
+
+func (ec *elasticClient) startScroll(ctx context.Context, query *bytes.Buffer, index string) ([]byte, error) {
+	queryBytes := query.Bytes()
+
+	var bodyBytes []byte
+	err := ec.retryHandler.Do(ctx, "scroll "+index, func() error {
+		reqCtx, cancel := ec.requestContext(ctx)
+		defer cancel()
+
+		res, err := ec.client.Search(
+			ec.client.Search.WithSize(pageSize),
+			ec.client.Search.WithScroll(keepAlive),
+			ec.client.Search.WithContext(reqCtx),
+			ec.client.Search.WithIndex(index),
+			ec.client.Search.WithBody(bytes.NewReader(queryBytes)),
+		)
+		if err != nil {
+			return err
+		}
+
+		bodyBytes, err = getBytesFromResponse(res)
+		return err
+	})
+
+	return bodyBytes, err
 }
 
 func (ec *elasticClient) iterateScroll(
+	ctx context.Context,
 	scrollID string,
 	handlerFunc func(responseBytes []byte) error,
 ) error {
 	if scrollID == "" {
 		return nil
 	}
-	defer func() {
-		err := ec.clearScroll(scrollID)
-		if err != nil {
-			log.Print("cannot clear scroll ", err)
-		}
-	}()
 
 	for {
-		scrollBodyBytes, errScroll := ec.getScrollResponse(scrollID)
+		scrollBodyBytes, errScroll := ec.getScrollResponse(ctx, scrollID)
+		if errScroll != nil && ctx.Err() == nil && !errors.Is(errScroll, data.ErrScrollExpired) {
+			// a failed page is not requested again, the scroll might have moved past it on the server
+			return fmt.Errorf("%w: %s", data.ErrScrollExpired, errScroll.Error())
+		}
 		if errScroll != nil {
 			return errScroll
 		}
@@ -94,24 +283,34 @@ func (ec *elasticClient) iterateScroll(
 
 }
 
-func (ec *elasticClient) getScrollResponse(scrollID string) ([]byte, error) {
-	ec.counter++
+func (ec *elasticClient) getScrollResponse(ctx context.Context, scrollID string) ([]byte, error) {
+	reqCtx, cancel := ec.requestContext(ctx)
+	defer cancel()
+
 	res, err := ec.client.Scroll(
+		ec.client.Scroll.WithContext(reqCtx),
 		ec.client.Scroll.WithScrollID(scrollID),
-		ec.client.Scroll.WithScroll(5*time.Minute+time.Duration(ec.counter)*time.Millisecond),
+		ec.client.Scroll.WithScroll(keepAlive),
 	)
 	if err != nil {
 		return nil, err
 	}
+	if res.StatusCode == http.StatusNotFound {
+		defer closeBody(res)
+		return nil, fmt.Errorf("%w: %s", data.ErrScrollExpired, res)
+	}
 
 	return getBytesFromResponse(res)
 }
 
 func getBytesFromResponse(res *esapi.Response) ([]byte, error) {
+	defer closeBody(res)
 	if res.IsError() {
-		return nil, fmt.Errorf("error response: %s", res)
+		return nil, &retry.StatusError{
+			StatusCode: res.StatusCode,
+			Message:    fmt.Sprintf("error response: %s", res),
+		}
 	}
-	defer closeBody(res)
 
 	bodyBytes, err := ioutil.ReadAll(res.Body)
 	if err != nil {
@@ -121,8 +320,13 @@ func getBytesFromResponse(res *esapi.Response) ([]byte, error) {
 	return bodyBytes, nil
 }
 
+// clearScroll does not use the context of the run, so the scroll is cleared also when the run was interrupted
 func (ec *elasticClient) clearScroll(scrollID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), clearScrollTimeout)
+	defer cancel()
+
 	resp, err := ec.client.ClearScroll(
+		ec.client.ClearScroll.WithContext(ctx),
 		ec.client.ClearScroll.WithScrollID(scrollID),
 	)
 	if err != nil {
@@ -144,27 +348,88 @@ func closeBody(res *esapi.Response) {
 }
 
 // DoSearchRequest wil do a search request to elaticsearch server
-func (ec *elasticClient) DoSearchRequest(query *bytes.Buffer, index string) ([]byte, error) {
-	ec.counter++
-	timeout := 5*time.Minute + time.Duration(ec.counter)*time.Millisecond
-	res, err := ec.client.Search(
-		ec.client.Search.WithBody(query),
-		ec.client.Search.WithIndex(index),
-		ec.client.Search.WithTimeout(timeout),
+func (ec *elasticClient) DoSearchRequest(ctx context.Context, query *bytes.Buffer, index string) ([]byte, error) {
+	return ec.search(ctx, query, index)
+}
+
+// DoAggregationRequest will do a search request that returns no documents and will return only the aggregations
+// of the response
+func (ec *elasticClient) DoAggregationRequest(ctx context.Context, query *bytes.Buffer, index string) ([]byte, error) {
+	bodyBytes, err := ec.search(ctx, query, index,
+		ec.client.Search.WithSize(0),
+		ec.client.Search.WithTrackTotalHits(false),
 	)
 	if err != nil {
 		return nil, err
 	}
-	if res.IsError() {
-		return nil, fmt.Errorf("%s", res.String())
+
+	aggregations := gjson.GetBytes(bodyBytes, "aggregations")
+	if !aggregations.Exists() {
+		return nil, fmt.Errorf("no aggregations in the response of index %s", index)
 	}
 
-	defer closeBody(res)
+	return []byte(aggregations.Raw), nil
+}
+
+func (ec *elasticClient) search(ctx context.Context, query *bytes.Buffer, index string, options ...func(*esapi.SearchRequest)) ([]byte, error) {
+	queryBytes := query.Bytes()
+
+	var bodyBytes []byte
+	err := ec.retryHandler.Do(ctx, "search "+index, func() error {
+		reqCtx, cancel := ec.requestContext(ctx)
+		defer cancel()
+
+		searchOptions := []func(*esapi.SearchRequest){
+			ec.client.Search.WithContext(reqCtx),
+			ec.client.Search.WithBody(bytes.NewReader(queryBytes)),
+			ec.client.Search.WithIndex(index),
+			ec.client.Search.WithTimeout(searchTimeout),
+		}
+		res, err := ec.client.Search(append(searchOptions, options...)...)
+		if err != nil {
+			return err
+		}
+
+		bodyBytes, err = getBytesFromResponse(res)
+		return err
+	})
 
-	bodyBytes, errRead := ioutil.ReadAll(res.Body)
-	if errRead != nil {
-		return nil, errRead
+	return bodyBytes, err
+}
+
+// DoBulkRequest will do a bulk request to elasticsearch server
+func (ec *elasticClient) DoBulkRequest(ctx context.Context, buff *bytes.Buffer, index string) error {
+	var bodyBytes []byte
+	err := ec.retryHandler.Do(ctx, "bulk "+index, func() error {
+		reqCtx, cancel := ec.requestContext(ctx)
+		defer cancel()
+
+		res, err := ec.client.Bulk(
+			bytes.NewReader(buff.Bytes()),
+			ec.client.Bulk.WithContext(reqCtx),
+			ec.client.Bulk.WithIndex(index),
+		)
+		if err != nil {
+			return err
+		}
+
+		bodyBytes, err = getBytesFromResponse(res)
+		return err
+	})
+	if err != nil {
+		return err
 	}
 
-	return bodyBytes, nil
+	if !gjson.GetBytes(bodyBytes, "errors").Bool() {
+		return nil
+	}
+
+	for _, item := range gjson.GetBytes(bodyBytes, "items").Array() {
+		itemError := item.Get("index.error")
+		if itemError.Exists() {
+			return fmt.Errorf("bulk request to index %s failed: %s", index, itemError.String())
+		}
+	}
+
+	return fmt.Errorf("bulk request to index %s failed", index)
 }
@@ -0,0 +1,139 @@
+package elasticClient
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ElrondNetwork/statistics-go/data"
+	"github.com/elastic/go-elasticsearch/v7"
+	"github.com/tidwall/gjson"
+)
+
+type noRetry struct{}
+
+func (nr *noRetry) Do(_ context.Context, _ string, action func() error) error {
+	return action()
+}
+
+// newScrollServer serves one page with a document for every slice, the document id is the slice id. The scrolls
+// of the slices listed in expired are lost after the first page
+func newScrollServer(t *testing.T, expired map[string]bool) (*httptest.Server, *sync.Map) {
+	cleared := &sync.Map{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+
+		switch {
+		case r.Method == http.MethodDelete:
+			cleared.Store(strings.TrimPrefix(r.URL.Path, "/_search/scroll/"), true)
+			_, _ = w.Write([]byte(`{}`))
+		case strings.HasSuffix(r.URL.Path, "/_search/scroll"):
+			scrollID := r.URL.Query().Get("scroll_id")
+			if expired[scrollID] {
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`{"error":"search_context_missing_exception"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"hits":{"hits":[]}}`))
+		default:
+			sliceID := gjson.GetBytes(body, "slice.id").String()
+			if gjson.GetBytes(body, "slice.max").Int() != 3 {
+				t.Errorf("unexpected query %s", body)
+			}
+			_, _ = fmt.Fprintf(w, `{"_scroll_id":"%s","hits":{"hits":[{"_id":"%s"}]}}`, sliceID, sliceID)
+		}
+	}))
+
+	return server, cleared
+}
+
+func TestElasticClient_DoSlicedScrollRequestAllDocuments(t *testing.T) {
+	server, cleared := newScrollServer(t, map[string]bool{})
+	defer server.Close()
+
+	ec, err := NewElasticClient(elasticsearch.Config{Addresses: []string{server.URL}}, 0, &noRetry{}, 2, PaginationScroll)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ids := make(map[string]int)
+	err = ec.DoSlicedScrollRequestAllDocuments(context.Background(), bytes.NewBufferString(`{"query":{"match_all":{}}}`), "transactions", 3, func(responseBytes []byte) error {
+		// the pages are passed one at a time, so the handler needs no locking
+		ids[gjson.GetBytes(responseBytes, "hits.hits.0._id").String()]++
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(ids) != 3 || ids["0"] != 1 || ids["1"] != 1 || ids["2"] != 1 {
+		t.Fatalf("expected one page from every slice, got %v", ids)
+	}
+	for _, sliceID := range []string{"0", "1", "2"} {
+		if _, ok := cleared.Load(sliceID); !ok {
+			t.Fatalf("the scroll of slice %s was not cleared", sliceID)
+		}
+	}
+}
+
+func TestElasticClient_SlicedScrollReportsExpiredSlice(t *testing.T) {
+	server, _ := newScrollServer(t, map[string]bool{"1": true})
+	defer server.Close()
+
+	ec, _ := NewElasticClient(elasticsearch.Config{Addresses: []string{server.URL}}, 0, &noRetry{}, 3, PaginationScroll)
+
+	err := ec.DoSlicedScrollRequestAllDocuments(context.Background(), bytes.NewBufferString(`{}`), "transactions", 3, func(_ []byte) error {
+		return nil
+	})
+	if !errors.Is(err, data.ErrScrollExpired) {
+		t.Fatalf("expected the scroll expired error, got %v", err)
+	}
+}
+
+func TestWithSlice(t *testing.T) {
+	query, err := withSlice([]byte(`{"query":{"match_all":{}}}`), 2, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if gjson.Get(query.String(), "slice.id").Int() != 2 || gjson.Get(query.String(), "slice.max").Int() != 4 {
+		t.Fatalf("unexpected query %s", query.String())
+	}
+	if !gjson.Get(query.String(), "query.match_all").Exists() {
+		t.Fatalf("the original query was lost: %s", query.String())
+	}
+
+	_, err = withSlice([]byte(`not json`), 0, 2)
+	if err == nil {
+		t.Fatal("expected error for an invalid query")
+	}
+}
+
+func TestElasticClient_DoAggregationRequest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("size") != "0" {
+			t.Errorf("expected a request without documents, got %s", r.URL.RawQuery)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"hits":{"hits":[]},"aggregations":{"transactions":{"buckets":[{"doc_count":7}]}}}`))
+	}))
+	defer server.Close()
+
+	ec, _ := NewElasticClient(elasticsearch.Config{Addresses: []string{server.URL}}, 0, &noRetry{}, 1, PaginationScroll)
+
+	aggregations, err := ec.DoAggregationRequest(context.Background(), bytes.NewBufferString(`{"aggs":{}}`), "transactions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gjson.GetBytes(aggregations, "transactions.buckets.0.doc_count").Int() != 7 {
+		t.Fatalf("unexpected aggregations %s", aggregations)
+	}
+}
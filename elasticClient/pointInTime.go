@@ -0,0 +1,261 @@
+package elasticClient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/ElrondNetwork/statistics-go/data"
+	"github.com/tidwall/gjson"
+)
+
+// maxPointInTimeReopens bounds how many times a lost point in time is replaced while reading the documents of a query
+const maxPointInTimeReopens = 3
+
+// pointInTimeSort orders the documents on their timestamp and then on their position in the point in time, which
+// does not need fielddata like the _id. The position is valid only in the point in time that returned it
+var pointInTimeSort = []interface{}{
+	map[string]interface{}{"timestamp": "asc"},
+	map[string]interface{}{"_shard_doc": "asc"},
+}
+
+// pointInTimeCursor keeps the sort key after which the next page is read. In a new point in time the positions
+// change, so the reading continues from the first document of the last seen timestamp and the documents of that
+// timestamp which were already handled are skipped
+type pointInTimeCursor struct {
+	searchAfter   json.RawMessage
+	lastTimestamp string
+	seenIDs       map[string]struct{}
+}
+
+func (pc *pointInTimeCursor) advance(hits []gjson.Result) {
+	for _, hit := range hits {
+		timestamp := hit.Get("sort.0").Raw
+		if timestamp != pc.lastTimestamp {
+			pc.lastTimestamp = timestamp
+			pc.seenIDs = make(map[string]struct{})
+		}
+		pc.seenIDs[hit.Get("_id").String()] = struct{}{}
+	}
+
+	pc.searchAfter = json.RawMessage(hits[len(hits)-1].Get("sort").Raw)
+}
+
+// restart moves the cursor before the first document of the last seen timestamp, the positions start from 0
+func (pc *pointInTimeCursor) restart() {
+	if len(pc.searchAfter) == 0 {
+		return
+	}
+
+	pc.searchAfter = json.RawMessage(fmt.Sprintf("[%s,-1]", pc.lastTimestamp))
+}
+
+// unseen returns the hits that were not handled before a restart
+func (pc *pointInTimeCursor) unseen(hits []gjson.Result) []gjson.Result {
+	unseenHits := make([]gjson.Result, 0, len(hits))
+	for _, hit := range hits {
+		_, seen := pc.seenIDs[hit.Get("_id").String()]
+		if seen && hit.Get("sort.0").Raw == pc.lastTimestamp {
+			continue
+		}
+		unseenHits = append(unseenHits, hit)
+	}
+
+	return unseenHits
+}
+
+// doPointInTimeRequestAllDocuments reads all the documents that match the query page by page with search_after over a
+// point in time. The pages are requested again until the retries are exhausted, and when the point in time expires a
+// new one is opened and the reading continues after the documents already handled
+func (ec *elasticClient) doPointInTimeRequestAllDocuments(
+	ctx context.Context,
+	query *bytes.Buffer,
+	index string,
+	handlerFunc func(responseBytes []byte) error,
+) error {
+	queryObject := make(map[string]interface{})
+	err := json.Unmarshal(query.Bytes(), &queryObject)
+	if err != nil {
+		return fmt.Errorf("cannot read the query: %w", err)
+	}
+
+	pitID, err := ec.openPointInTime(ctx, index)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		ec.closePointInTime(pitID)
+	}()
+
+	cursor := &pointInTimeCursor{}
+	reopens := 0
+	for {
+		bodyBytes, errSearch := ec.searchPointInTime(ctx, queryObject, pitID, cursor.searchAfter)
+		if errors.Is(errSearch, data.ErrScrollExpired) && reopens < maxPointInTimeReopens {
+			reopens++
+			log.Printf("point in time of index %s expired, continue in a new one (%d/%d)", index, reopens, maxPointInTimeReopens)
+
+			ec.closePointInTime(pitID)
+			pitID, err = ec.openPointInTime(ctx, index)
+			if err != nil {
+				return err
+			}
+			cursor.restart()
+			continue
+		}
+		if errSearch != nil {
+			return errSearch
+		}
+
+		// the point in time id can change between pages
+		newPitID := gjson.GetBytes(bodyBytes, "pit_id").String()
+		if newPitID != "" {
+			pitID = newPitID
+		}
+
+		hits := gjson.GetBytes(bodyBytes, "hits.hits").Array()
+		if len(hits) == 0 {
+			return nil
+		}
+
+		unseenHits := cursor.unseen(hits)
+		if len(unseenHits) < len(hits) {
+			bodyBytes = hitsResponse(unseenHits)
+		}
+		if len(unseenHits) > 0 {
+			err = handlerFunc(bodyBytes)
+			if err != nil {
+				return err
+			}
+		}
+
+		cursor.advance(hits)
+		if len(hits) < pageSize {
+			return nil
+		}
+	}
+}
+
+// hitsResponse builds a search response with only the provided hits, which is what the handlers read
+func hitsResponse(hits []gjson.Result) []byte {
+	rawHits := make([]string, len(hits))
+	for idx, hit := range hits {
+		rawHits[idx] = hit.Raw
+	}
+
+	return []byte(fmt.Sprintf(`{"hits":{"hits":[%s]}}`, strings.Join(rawHits, ",")))
+}
+
+func (ec *elasticClient) openPointInTime(ctx context.Context, index string) (string, error) {
+	var pitID string
+	err := ec.retryHandler.Do(ctx, "open point in time "+index, func() error {
+		reqCtx, cancel := ec.requestContext(ctx)
+		defer cancel()
+
+		res, err := ec.client.OpenPointInTime(
+			ec.client.OpenPointInTime.WithContext(reqCtx),
+			ec.client.OpenPointInTime.WithIndex(index),
+			ec.client.OpenPointInTime.WithKeepAlive(keepAliveString()),
+		)
+		if err != nil {
+			return err
+		}
+
+		bodyBytes, err := getBytesFromResponse(res)
+		if err != nil {
+			return err
+		}
+
+		pitID = gjson.GetBytes(bodyBytes, "id").String()
+		if pitID == "" {
+			return fmt.Errorf("no point in time id in response %s", bodyBytes)
+		}
+
+		return nil
+	})
+
+	return pitID, err
+}
+
+// searchPointInTime requests the page after the provided sort key, the first page when it is empty
+func (ec *elasticClient) searchPointInTime(
+	ctx context.Context,
+	queryObject map[string]interface{},
+	pitID string,
+	searchAfter json.RawMessage,
+) ([]byte, error) {
+	pageQuery := make(map[string]interface{}, len(queryObject)+4)
+	for key, value := range queryObject {
+		pageQuery[key] = value
+	}
+	pageQuery["pit"] = map[string]interface{}{
+		"id":         pitID,
+		"keep_alive": keepAliveString(),
+	}
+	pageQuery["size"] = pageSize
+	pageQuery["sort"] = pointInTimeSort
+	pageQuery["track_total_hits"] = false
+	if len(searchAfter) > 0 {
+		pageQuery["search_after"] = searchAfter
+	}
+
+	pageQueryBytes, err := json.Marshal(pageQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	var bodyBytes []byte
+	err = ec.retryHandler.Do(ctx, "search point in time", func() error {
+		reqCtx, cancel := ec.requestContext(ctx)
+		defer cancel()
+
+		// the index is not set, it is part of the point in time
+		res, err := ec.client.Search(
+			ec.client.Search.WithContext(reqCtx),
+			ec.client.Search.WithBody(bytes.NewReader(pageQueryBytes)),
+		)
+		if err != nil {
+			return err
+		}
+		if res.StatusCode == http.StatusNotFound {
+			defer closeBody(res)
+			return fmt.Errorf("%w: %s", data.ErrScrollExpired, res)
+		}
+
+		bodyBytes, err = getBytesFromResponse(res)
+		return err
+	})
+
+	return bodyBytes, err
+}
+
+// closePointInTime does not use the context of the run, so the point in time is closed also when the run was
+// interrupted
+func (ec *elasticClient) closePointInTime(pitID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), clearScrollTimeout)
+	defer cancel()
+
+	body := fmt.Sprintf(`{"id":%q}`, pitID)
+	res, err := ec.client.ClosePointInTime(
+		ec.client.ClosePointInTime.WithContext(ctx),
+		ec.client.ClosePointInTime.WithBody(strings.NewReader(body)),
+	)
+	if err != nil {
+		log.Print("cannot close point in time ", err)
+		return
+	}
+	defer closeBody(res)
+
+	if res.IsError() && res.StatusCode != http.StatusNotFound {
+		log.Print("cannot close point in time ", res.String())
+	}
+}
+
+func keepAliveString() string {
+	return fmt.Sprintf("%dm", int(keepAlive.Minutes()))
+}
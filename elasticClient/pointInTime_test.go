@@ -0,0 +1,137 @@
+package elasticClient
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/elastic/go-elasticsearch/v7"
+	"github.com/tidwall/gjson"
+)
+
+// pointInTimeServer serves a full page and then a page with a single document. The first point in time expires
+// after the first page when expireFirst is set, and a new point in time serves again the last document of the full
+// page, which has the same timestamp, before the last document
+type pointInTimeServer struct {
+	t           *testing.T
+	expireFirst bool
+
+	mut          sync.Mutex
+	opened       int
+	closed       []string
+	searchAfters []string
+}
+
+func (pts *pointInTimeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	pts.mut.Lock()
+	defer pts.mut.Unlock()
+
+	body, _ := ioutil.ReadAll(r.Body)
+	w.Header().Set("Content-Type", "application/json")
+
+	switch {
+	case strings.HasSuffix(r.URL.Path, "/_pit") && r.Method == http.MethodDelete:
+		pts.closed = append(pts.closed, gjson.GetBytes(body, "id").String())
+		_, _ = w.Write([]byte(`{"succeeded":true}`))
+	case strings.HasSuffix(r.URL.Path, "/_pit"):
+		if r.URL.Query().Get("keep_alive") == "" {
+			pts.t.Errorf("no keep alive when opening the point in time")
+		}
+		pts.opened++
+		_, _ = fmt.Fprintf(w, `{"id":"pit-%d"}`, pts.opened)
+	case r.URL.Path == "/_search":
+		pitID := gjson.GetBytes(body, "pit.id").String()
+		searchAfter := gjson.GetBytes(body, "search_after").Raw
+		if searchAfter == "" {
+			_, _ = w.Write(fullPage(pitID))
+			return
+		}
+
+		pts.searchAfters = append(pts.searchAfters, searchAfter)
+		if pitID == "pit-1" && pts.expireFirst {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":"search_context_missing_exception"}`))
+			return
+		}
+		lastHit := `{"_id":"last","sort":[99999,1]}`
+		if pitID != "pit-1" {
+			lastHit = fmt.Sprintf(`{"_id":"h%d","sort":[%d,3]},%s`, pageSize-1, pageSize-1, lastHit)
+		}
+		_, _ = fmt.Fprintf(w, `{"pit_id":"%s","hits":{"hits":[%s]}}`, pitID, lastHit)
+	default:
+		pts.t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+	}
+}
+
+func fullPage(pitID string) []byte {
+	hits := make([]string, pageSize)
+	for idx := range hits {
+		hits[idx] = fmt.Sprintf(`{"_id":"h%d","sort":[%d,%d]}`, idx, idx, idx)
+	}
+
+	return []byte(fmt.Sprintf(`{"pit_id":"%s","hits":{"hits":[%s]}}`, pitID, strings.Join(hits, ",")))
+}
+
+func readAllWithPointInTime(t *testing.T, pts *pointInTimeServer) int {
+	server := httptest.NewServer(pts)
+	defer server.Close()
+
+	ec, err := NewElasticClient(elasticsearch.Config{Addresses: []string{server.URL}}, 0, &noRetry{}, 1, PaginationPointInTime)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	numDocuments := 0
+	err = ec.DoScrollRequestAllDocuments(context.Background(), bytes.NewBufferString(`{"query":{"match_all":{}}}`), "transactions", func(responseBytes []byte) error {
+		numDocuments += len(gjson.GetBytes(responseBytes, "hits.hits").Array())
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return numDocuments
+}
+
+func TestElasticClient_PointInTimeReadsAllThePages(t *testing.T) {
+	pts := &pointInTimeServer{t: t}
+
+	numDocuments := readAllWithPointInTime(t, pts)
+	if numDocuments != pageSize+1 {
+		t.Fatalf("expected %d documents, got %d", pageSize+1, numDocuments)
+	}
+
+	expectedSearchAfter := fmt.Sprintf(`[%d,%d]`, pageSize-1, pageSize-1)
+	if len(pts.searchAfters) != 1 || pts.searchAfters[0] != expectedSearchAfter {
+		t.Fatalf("expected the second page after %s, got %v", expectedSearchAfter, pts.searchAfters)
+	}
+	if len(pts.closed) != 1 || pts.closed[0] != "pit-1" {
+		t.Fatalf("expected the point in time to be closed, got %v", pts.closed)
+	}
+}
+
+func TestElasticClient_ExpiredPointInTimeContinuesFromTheLastTimestamp(t *testing.T) {
+	pts := &pointInTimeServer{t: t, expireFirst: true}
+
+	numDocuments := readAllWithPointInTime(t, pts)
+	if numDocuments != pageSize+1 {
+		t.Fatalf("expected %d documents without duplicates, got %d", pageSize+1, numDocuments)
+	}
+
+	if pts.opened != 2 {
+		t.Fatalf("expected a new point in time, got %d opened", pts.opened)
+	}
+	expectedSearchAfter := fmt.Sprintf(`[%d,-1]`, pageSize-1)
+	if len(pts.searchAfters) != 2 || pts.searchAfters[1] != expectedSearchAfter {
+		t.Fatalf("expected the new point in time to continue from the last timestamp %s, got %v", expectedSearchAfter, pts.searchAfters)
+	}
+	if len(pts.closed) != 2 {
+		t.Fatalf("expected both points in time to be closed, got %v", pts.closed)
+	}
+}
@@ -7,6 +7,10 @@ require (
 	github.com/ElrondNetwork/elrond-go v1.1.38-0.20210322081509-33c2aae0b88c
 	github.com/ElrondNetwork/elrond-go-logger v1.0.4
 	github.com/elastic/go-elasticsearch/v7 v7.12.0
+	github.com/prometheus/client_golang v1.11.0
 	github.com/tidwall/gjson v1.7.4
 	github.com/urfave/cli v1.22.5
+	github.com/xitongsys/parquet-go v1.6.2
+	github.com/xitongsys/parquet-go-source v0.0.0-20200817004010-026bad9b25d0
+	modernc.org/sqlite v1.11.2
 )
@@ -0,0 +1,174 @@
+package metrics
+
+import (
+	"math/big"
+	"net/http"
+	"time"
+
+	"github.com/ElrondNetwork/statistics-go/data"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "statistics"
+
+// oneEGLD is the number of denominated units in one EGLD
+var oneEGLD = big.NewFloat(1e18)
+
+type prometheusMetrics struct {
+	registry *prometheus.Registry
+
+	latestEpoch        *prometheus.GaugeVec
+	transactionsGauges map[string]prometheus.Gauge
+	accountsGauges     map[string]prometheus.Gauge
+	balanceBuckets     *prometheus.GaugeVec
+	stakeGauges        map[string]prometheus.Gauge
+	scrollPages        *prometheus.CounterVec
+	epochDuration      *prometheus.HistogramVec
+	failedEpochs       *prometheus.CounterVec
+}
+
+// NewPrometheusMetrics will create a new instance of prometheusMetrics that exposes, in its own registry, the
+// statistics of the latest epoch as gauges together with the processing metrics
+func NewPrometheusMetrics() (*prometheusMetrics, error) {
+	pm := &prometheusMetrics{
+		registry: prometheus.NewRegistry(),
+		latestEpoch: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "latest_epoch",
+			Help:      "The latest epoch of every statistic",
+		}, []string{"statistic"}),
+		transactionsGauges: map[string]prometheus.Gauge{
+			"daily_transactions":             newGauge("daily_transactions", "The transactions of the latest epoch"),
+			"daily_contract_calls":           newGauge("daily_contract_calls", "The smart contract calls of the latest epoch"),
+			"daily_active_accounts":          newGauge("daily_active_accounts", "The active accounts of the latest epoch"),
+			"daily_active_contract_accounts": newGauge("daily_active_contract_accounts", "The active contracts of the latest epoch"),
+			"daily_new_addresses":            newGauge("daily_new_addresses", "The new addresses of the latest epoch"),
+			"daily_new_contract_addresses":   newGauge("daily_new_contract_addresses", "The new contracts of the latest epoch"),
+		},
+		accountsGauges: map[string]prometheus.Gauge{
+			"total_addresses":    newGauge("total_addresses", "The addresses with a balance in the latest epoch"),
+			"non_zero_addresses": newGauge("non_zero_addresses", "The addresses with a non zero balance in the latest epoch"),
+		},
+		balanceBuckets: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "balance_bucket_addresses",
+			Help:      "The addresses with a balance greater or equal than the threshold in EGLD in the latest epoch",
+		}, []string{"threshold"}),
+		stakeGauges: map[string]prometheus.Gauge{
+			"total_staked_egld":           newGauge("total_staked_egld", "The total staked EGLD in the latest epoch"),
+			"staking_users":               newGauge("staking_users", "The staking users in the latest epoch"),
+			"legacy_delegation_users":     newGauge("legacy_delegation_users", "The legacy delegation users in the latest epoch"),
+			"total_delegated_legacy_egld": newGauge("total_delegated_legacy_egld", "The EGLD in the legacy delegation in the latest epoch"),
+			"delegation_users":            newGauge("delegation_users", "The delegation users in the latest epoch"),
+			"delegation_egld":             newGauge("delegation_egld", "The delegated EGLD in the latest epoch"),
+		},
+		scrollPages: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "elastic_scroll_pages_total",
+			Help:      "The scroll pages fetched from elasticsearch",
+		}, []string{"index"}),
+		epochDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "epoch_processing_duration_seconds",
+			Help:      "The processing duration of every successfully processed epoch",
+			Buckets:   prometheus.ExponentialBuckets(1, 2, 14),
+		}, []string{"statistic"}),
+		failedEpochs: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "epochs_failed_total",
+			Help:      "The epochs that failed to be processed",
+		}, []string{"statistic"}),
+	}
+
+	collectors := []prometheus.Collector{pm.latestEpoch, pm.balanceBuckets, pm.scrollPages, pm.epochDuration, pm.failedEpochs}
+	for _, gauges := range []map[string]prometheus.Gauge{pm.transactionsGauges, pm.accountsGauges, pm.stakeGauges} {
+		for _, gauge := range gauges {
+			collectors = append(collectors, gauge)
+		}
+	}
+
+	for _, collector := range collectors {
+		err := pm.registry.Register(collector)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return pm, nil
+}
+
+func newGauge(name string, help string) prometheus.Gauge {
+	return prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      name,
+		Help:      help,
+	})
+}
+
+// Handler returns the http handler of the /metrics endpoint
+func (pm *prometheusMetrics) Handler() http.Handler {
+	return promhttp.HandlerFor(pm.registry, promhttp.HandlerOpts{})
+}
+
+// ScrollPageFetched will count a scroll page fetched from the provided index
+func (pm *prometheusMetrics) ScrollPageFetched(index string) {
+	pm.scrollPages.WithLabelValues(index).Inc()
+}
+
+// EpochProcessed will observe the processing duration of a successful epoch or count a failed one
+func (pm *prometheusMetrics) EpochProcessed(statistic string, duration time.Duration, failed bool) {
+	if failed {
+		pm.failedEpochs.WithLabelValues(statistic).Inc()
+		return
+	}
+
+	pm.epochDuration.WithLabelValues(statistic).Observe(duration.Seconds())
+}
+
+// SetLatestTransactions will publish the transactions statistics of the latest epoch
+func (pm *prometheusMetrics) SetLatestTransactions(record *data.StatisticsEpoch) {
+	pm.latestEpoch.WithLabelValues("transactions").Set(float64(record.Epoch))
+	pm.transactionsGauges["daily_transactions"].Set(float64(record.DailyTransactions))
+	pm.transactionsGauges["daily_contract_calls"].Set(float64(record.DailyContractCalls))
+	pm.transactionsGauges["daily_active_accounts"].Set(float64(record.DailyActiveAccounts))
+	pm.transactionsGauges["daily_active_contract_accounts"].Set(float64(record.DailyActiveContractAccounts))
+	pm.transactionsGauges["daily_new_addresses"].Set(float64(record.DailyNewAddresses))
+	pm.transactionsGauges["daily_new_contract_addresses"].Set(float64(record.DailyNewContractAddresses))
+}
+
+// SetLatestAccounts will publish the accounts statistics of the latest epoch
+func (pm *prometheusMetrics) SetLatestAccounts(record *data.StatisticsAddressesBalanceEpoch) {
+	pm.latestEpoch.WithLabelValues("accounts").Set(float64(record.Epoch))
+	pm.accountsGauges["total_addresses"].Set(float64(record.TotalAddresses))
+	pm.accountsGauges["non_zero_addresses"].Set(float64(record.NonZero))
+
+	// the thresholds can change between runs, so the buckets of the previous epoch are not kept
+	pm.balanceBuckets.Reset()
+	for _, bucket := range record.BalanceDistribution {
+		pm.balanceBuckets.WithLabelValues(bucket.Threshold).Set(float64(bucket.Count))
+	}
+}
+
+// SetLatestStake will publish the stake statistics of the latest epoch
+func (pm *prometheusMetrics) SetLatestStake(record *data.StakeInfoEpoch) {
+	pm.latestEpoch.WithLabelValues("stake").Set(float64(record.Epoch))
+	pm.stakeGauges["total_staked_egld"].Set(denominatedToEGLD(record.TotalStaked))
+	pm.stakeGauges["staking_users"].Set(float64(record.StakingUsers))
+	pm.stakeGauges["legacy_delegation_users"].Set(float64(record.LegacyDelegationUser))
+	pm.stakeGauges["total_delegated_legacy_egld"].Set(denominatedToEGLD(record.TotalDelegatedLegacy))
+	pm.stakeGauges["delegation_users"].Set(float64(record.DelegationUsers))
+	pm.stakeGauges["delegation_egld"].Set(denominatedToEGLD(record.Delegation))
+}
+
+// denominatedToEGLD converts a denominated amount to EGLD, an invalid amount is reported as 0
+func denominatedToEGLD(value string) float64 {
+	amount, ok := big.NewFloat(0).SetString(value)
+	if !ok {
+		return 0
+	}
+
+	egld, _ := amount.Quo(amount, oneEGLD).Float64()
+
+	return egld
+}
@@ -0,0 +1,60 @@
+package metrics
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ElrondNetwork/statistics-go/data"
+)
+
+func TestPrometheusMetrics_Handler(t *testing.T) {
+	pm, err := NewPrometheusMetrics()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pm.SetLatestTransactions(&data.StatisticsEpoch{Epoch: 10, DailyTransactions: 1500})
+	pm.SetLatestAccounts(&data.StatisticsAddressesBalanceEpoch{
+		Epoch:               10,
+		BalanceDistribution: []*data.BalanceBucket{{Threshold: "0.1", Count: 7}},
+	})
+	pm.SetLatestStake(&data.StakeInfoEpoch{Epoch: 9, TotalStaked: "2500000000000000000"})
+	pm.ScrollPageFetched("transactions")
+	pm.ScrollPageFetched("transactions")
+	pm.EpochProcessed("transactions", 2*time.Second, false)
+	pm.EpochProcessed("transactions", 3*time.Second, false)
+	pm.EpochProcessed("accounts", time.Second, true)
+
+	recorder := httptest.NewRecorder()
+	pm.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body, _ := ioutil.ReadAll(recorder.Body)
+
+	expectedLines := []string{
+		`statistics_latest_epoch{statistic="stake"} 9`,
+		`statistics_daily_transactions 1500`,
+		`statistics_balance_bucket_addresses{threshold="0.1"} 7`,
+		`statistics_total_staked_egld 2.5`,
+		`statistics_elastic_scroll_pages_total{index="transactions"} 2`,
+		`statistics_epoch_processing_duration_seconds_sum{statistic="transactions"} 5`,
+		`statistics_epoch_processing_duration_seconds_count{statistic="transactions"} 2`,
+		`statistics_epochs_failed_total{statistic="accounts"} 1`,
+	}
+	for _, line := range expectedLines {
+		if !strings.Contains(string(body), line) {
+			t.Fatalf("expected %s in the metrics:\n%s", line, body)
+		}
+	}
+}
+
+func TestDenominatedToEGLD(t *testing.T) {
+	if egld := denominatedToEGLD("1000000000000000000"); egld != 1 {
+		t.Fatalf("expected 1 EGLD, got %f", egld)
+	}
+	if egld := denominatedToEGLD(""); egld != 0 {
+		t.Fatalf("expected 0 EGLD for an empty amount, got %f", egld)
+	}
+}
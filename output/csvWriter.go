@@ -0,0 +1,149 @@
+package output
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+)
+
+type csvWriter struct {
+	merge bool
+}
+
+// NewCSVWriter will create a new instance of csvWriter
+func NewCSVWriter(merge bool) (*csvWriter, error) {
+	return &csvWriter{
+		merge: merge,
+	}, nil
+}
+
+// Write will write the flattened statistics records in the provided file and the side tables next to it
+func (cw *csvWriter) Write(filePath string, records interface{}) error {
+	mainTable, sideTables, err := flattenRecords(records)
+	if err != nil {
+		return err
+	}
+
+	writtenEpochs := mainTable.epochs()
+	for _, t := range append([]*table{mainTable}, sideTables...) {
+		err = cw.writeTable(tableFilePath(filePath, t.name), t, writtenEpochs)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (cw *csvWriter) writeTable(filePath string, t *table, writtenEpochs map[string]struct{}) error {
+	rows := t.rows
+	if cw.merge {
+		existingRows, err := readCSVRows(filePath)
+		if err != nil {
+			return err
+		}
+
+		rows, err = mergeRows(existingRows, rows, writtenEpochs)
+		if err != nil {
+			return err
+		}
+		padRows(rows, len(t.columns))
+	}
+
+	file, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	writer := csv.NewWriter(file)
+	err = writer.Write(t.columnNames())
+	if err != nil {
+		return err
+	}
+
+	err = writer.WriteAll(rows)
+	if err != nil {
+		return err
+	}
+
+	return file.Close()
+}
+
+// readCSVRows returns the rows of an existing csv file, without the header
+func readCSVRows(filePath string) ([][]string, error) {
+	file, err := os.Open(filePath)
+	if os.IsNotExist(err) {
+		return [][]string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	rows, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("cannot read existing output file %s: %w", filePath, err)
+	}
+	if len(rows) == 0 {
+		return rows, nil
+	}
+
+	return rows[1:], nil
+}
+
+// padRows fills with empty values the rows written before the table had all its columns, the new columns are
+// always appended at the end
+func padRows(rows [][]string, numColumns int) {
+	for idx, row := range rows {
+		for len(row) < numColumns {
+			row = append(row, "")
+		}
+		rows[idx] = row
+	}
+}
+
+// mergeRows replaces the existing rows of the written epochs with the new rows and keeps the rows ordered by epoch
+func mergeRows(existingRows [][]string, newRows [][]string, writtenEpochs map[string]struct{}) ([][]string, error) {
+	merged := make([][]string, 0, len(existingRows)+len(newRows))
+	for _, row := range existingRows {
+		if len(row) == 0 {
+			continue
+		}
+		if _, ok := writtenEpochs[row[0]]; ok {
+			continue
+		}
+		merged = append(merged, row)
+	}
+	merged = append(merged, newRows...)
+
+	epochs := make([]uint64, len(merged))
+	for idx, row := range merged {
+		epoch, err := strconv.ParseUint(row[0], 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid epoch %s in output row", row[0])
+		}
+		epochs[idx] = epoch
+	}
+
+	indexes := make([]int, len(merged))
+	for idx := range indexes {
+		indexes[idx] = idx
+	}
+	sort.SliceStable(indexes, func(i, j int) bool {
+		return epochs[indexes[i]] < epochs[indexes[j]]
+	})
+
+	sorted := make([][]string, len(merged))
+	for idx, originalIdx := range indexes {
+		sorted[idx] = merged[originalIdx]
+	}
+
+	return sorted, nil
+}
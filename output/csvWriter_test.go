@@ -0,0 +1,57 @@
+package output
+
+import (
+	"encoding/csv"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/ElrondNetwork/statistics-go/data"
+)
+
+func readCSVFile(t *testing.T, filePath string) [][]string {
+	file, err := os.Open(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	rows, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return rows
+}
+
+func TestCSVWriter_WriteWithSideTables(t *testing.T) {
+	filePath := path.Join(t.TempDir(), "output.csv")
+
+	writer, _ := NewCSVWriter(true)
+	err := writer.Write(filePath, []*data.StatisticsEpoch{
+		{Epoch: 0, DailyTransactions: 5, TopActiveAddresses: []*data.AddressCount{{Address: "erd1a", Count: 3}, {Address: "erd1b", Count: 2}}},
+		{Epoch: 1, DailyTransactions: 7, TopActiveAddresses: []*data.AddressCount{{Address: "erd1c", Count: 7}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = writer.Write(filePath, []*data.StatisticsEpoch{
+		{Epoch: 1, DailyTransactions: 8},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mainRows := readCSVFile(t, filePath)
+	if len(mainRows) != 3 || mainRows[0][0] != "epoch" || mainRows[2][1] != "8" {
+		t.Fatalf("unexpected main table %v", mainRows)
+	}
+
+	topRows := readCSVFile(t, path.Join(path.Dir(filePath), "output-topActiveAccounts.csv"))
+	if len(topRows) != 3 || topRows[1][2] != "erd1a" || topRows[2][2] != "erd1b" {
+		t.Fatalf("unexpected top addresses table %v", topRows)
+	}
+}
@@ -0,0 +1,108 @@
+package output
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ElrondNetwork/statistics-go/data"
+)
+
+const bulkRequestMaxDocuments = 1000
+
+type elasticSink struct {
+	elasticHandler    ElasticBulkHandler
+	transactionsIndex string
+	accountsIndex     string
+	stakeIndex        string
+}
+
+// NewElasticSink will create a new instance of elasticSink that indexes every statistics record in the index of its
+// statistic, using the epoch as document id so the records of the epochs that are processed again are overwritten
+func NewElasticSink(
+	elasticHandler ElasticBulkHandler,
+	transactionsIndex string,
+	accountsIndex string,
+	stakeIndex string,
+) (*elasticSink, error) {
+	if transactionsIndex == "" || accountsIndex == "" || stakeIndex == "" {
+		return nil, fmt.Errorf("empty statistics index name")
+	}
+
+	return &elasticSink{
+		elasticHandler:    elasticHandler,
+		transactionsIndex: transactionsIndex,
+		accountsIndex:     accountsIndex,
+		stakeIndex:        stakeIndex,
+	}, nil
+}
+
+// Publish will bulk index the statistics records
+func (es *elasticSink) Publish(ctx context.Context, records interface{}) error {
+	index, err := es.indexForRecords(records)
+	if err != nil {
+		return err
+	}
+
+	recordsBytes, err := json.Marshal(records)
+	if err != nil {
+		return err
+	}
+
+	documents := make([]json.RawMessage, 0)
+	err = json.Unmarshal(recordsBytes, &documents)
+	if err != nil {
+		return err
+	}
+
+	for start := 0; start < len(documents); start += bulkRequestMaxDocuments {
+		end := start + bulkRequestMaxDocuments
+		if end > len(documents) {
+			end = len(documents)
+		}
+
+		buff, errPrepare := prepareBulkBody(documents[start:end])
+		if errPrepare != nil {
+			return errPrepare
+		}
+
+		err = es.elasticHandler.DoBulkRequest(ctx, buff, index)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (es *elasticSink) indexForRecords(records interface{}) (string, error) {
+	switch records.(type) {
+	case []*data.StatisticsEpoch:
+		return es.transactionsIndex, nil
+	case []*data.StatisticsAddressesBalanceEpoch:
+		return es.accountsIndex, nil
+	case []*data.StakeInfoEpoch:
+		return es.stakeIndex, nil
+	default:
+		return "", fmt.Errorf("cannot index records of type %T", records)
+	}
+}
+
+func prepareBulkBody(documents []json.RawMessage) (*bytes.Buffer, error) {
+	buff := &bytes.Buffer{}
+	for _, document := range documents {
+		epoch, err := getRecordEpoch(document)
+		if err != nil {
+			return nil, err
+		}
+
+		meta := []byte(fmt.Sprintf(`{ "index" : { "_id" : "%d" } }%s`, epoch, "\n"))
+		buff.Grow(len(meta) + len(document) + 1)
+		buff.Write(meta)
+		buff.Write(document)
+		buff.WriteByte('\n')
+	}
+
+	return buff, nil
+}
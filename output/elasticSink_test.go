@@ -0,0 +1,48 @@
+package output
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ElrondNetwork/statistics-go/data"
+)
+
+type elasticBulkHandlerStub struct {
+	bodies  []string
+	indices []string
+}
+
+func (ebhs *elasticBulkHandlerStub) DoBulkRequest(_ context.Context, buff *bytes.Buffer, index string) error {
+	ebhs.bodies = append(ebhs.bodies, buff.String())
+	ebhs.indices = append(ebhs.indices, index)
+	return nil
+}
+
+func TestElasticSink_Publish(t *testing.T) {
+	handler := &elasticBulkHandlerStub{}
+	sink, _ := NewElasticSink(handler, "statistics-transactions", "statistics-accounts", "statistics-stake")
+
+	err := sink.Publish(context.Background(), []*data.StakeInfoEpoch{
+		{Epoch: 4, TotalStaked: "10"},
+		{Epoch: 5, TotalStaked: "20"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(handler.bodies) != 1 || handler.indices[0] != "statistics-stake" {
+		t.Fatalf("unexpected bulk requests %v", handler.indices)
+	}
+
+	lines := strings.Split(strings.TrimSpace(handler.bodies[0]), "\n")
+	if len(lines) != 4 || !strings.Contains(lines[0], `"_id" : "4"`) || !strings.Contains(lines[2], `"_id" : "5"`) {
+		t.Fatalf("unexpected bulk body %s", handler.bodies[0])
+	}
+
+	err = sink.Publish(context.Background(), "not statistics")
+	if err == nil {
+		t.Fatal("expected error for unknown records")
+	}
+}
@@ -0,0 +1,46 @@
+package output
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// FormatJSON writes all the records as an indented json array
+	FormatJSON = "json"
+	// FormatNDJSON writes every record as a json object on a separate line
+	FormatNDJSON = "ndjson"
+	// FormatCSV writes the flattened records as csv, the nested lists go to side tables in separate files
+	FormatCSV = "csv"
+	// FormatParquet writes the flattened records as parquet, the nested lists go to side tables in separate files
+	FormatParquet = "parquet"
+)
+
+// NewWriter will create the writer of the provided format. When merge is set, the records already present in the
+// output files are kept, except the ones of the epochs that are written again
+func NewWriter(format string, merge bool) (Writer, error) {
+	switch format {
+	case FormatJSON:
+		return NewJSONWriter(merge)
+	case FormatNDJSON:
+		return NewNDJSONWriter(merge)
+	case FormatCSV:
+		return NewCSVWriter(merge)
+	case FormatParquet:
+		return NewParquetWriter(merge)
+	default:
+		return nil, fmt.Errorf("invalid output format %s, expected %s, %s, %s or %s", format, FormatJSON, FormatNDJSON, FormatCSV, FormatParquet)
+	}
+}
+
+// tableFilePath returns the file of a side table, output.csv becomes output-topActiveAccounts.csv
+func tableFilePath(filePath string, tableName string) string {
+	if tableName == "" {
+		return filePath
+	}
+
+	extension := filepath.Ext(filePath)
+
+	return fmt.Sprintf("%s-%s%s", strings.TrimSuffix(filePath, extension), tableName, extension)
+}
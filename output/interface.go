@@ -0,0 +1,21 @@
+package output
+
+import (
+	"bytes"
+	"context"
+)
+
+// Writer defines what a writer of statistics records should be able to do
+type Writer interface {
+	Write(filePath string, records interface{}) error
+}
+
+// Sink defines what a destination of the statistics records, other than the output files, should be able to do
+type Sink interface {
+	Publish(ctx context.Context, records interface{}) error
+}
+
+// ElasticBulkHandler defines what an elasticsearch client used by a sink should be able to do
+type ElasticBulkHandler interface {
+	DoBulkRequest(ctx context.Context, buff *bytes.Buffer, index string) error
+}
@@ -0,0 +1,105 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+)
+
+type jsonWriter struct {
+	merge bool
+}
+
+// NewJSONWriter will create a new instance of jsonWriter. When merge is set, the records already present in the
+// output file are kept, except the ones of the epochs that are written again
+func NewJSONWriter(merge bool) (*jsonWriter, error) {
+	return &jsonWriter{
+		merge: merge,
+	}, nil
+}
+
+// Write will write the statistics records as an indented json array in the provided file
+func (jw *jsonWriter) Write(filePath string, records interface{}) error {
+	bytes, err := json.MarshalIndent(records, "", " ")
+	if err != nil {
+		return err
+	}
+
+	if jw.merge {
+		bytes, err = mergeWithExistingOutput(filePath, bytes)
+		if err != nil {
+			return err
+		}
+	}
+
+	return ioutil.WriteFile(filePath, bytes, 0644)
+}
+
+// mergeWithExistingOutput will merge the newly processed epochs in the records of an existing output file,
+// replacing the records of the epochs that were processed again and keeping the records ordered by epoch
+func mergeWithExistingOutput(outputFilePath string, newRecordsBytes []byte) ([]byte, error) {
+	existingBytes, err := ioutil.ReadFile(outputFilePath)
+	if os.IsNotExist(err) {
+		return newRecordsBytes, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	existingRecords := make([]json.RawMessage, 0)
+	err = json.Unmarshal(existingBytes, &existingRecords)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read existing output file %s: %w", outputFilePath, err)
+	}
+
+	newRecords := make([]json.RawMessage, 0)
+	err = json.Unmarshal(newRecordsBytes, &newRecords)
+	if err != nil {
+		return nil, err
+	}
+
+	mergedRecords, err := mergeRecords(existingRecords, newRecords)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.MarshalIndent(mergedRecords, "", " ")
+}
+
+// mergeRecords replaces the existing records of the epochs present in the new records and orders them by epoch
+func mergeRecords(existingRecords []json.RawMessage, newRecords []json.RawMessage) ([]json.RawMessage, error) {
+	recordsByEpoch := make(map[uint32]json.RawMessage)
+	for _, record := range append(existingRecords, newRecords...) {
+		epoch, errEpoch := getRecordEpoch(record)
+		if errEpoch != nil {
+			return nil, errEpoch
+		}
+		recordsByEpoch[epoch] = record
+	}
+
+	epochs := make([]uint32, 0, len(recordsByEpoch))
+	for epoch := range recordsByEpoch {
+		epochs = append(epochs, epoch)
+	}
+	sort.Slice(epochs, func(i, j int) bool {
+		return epochs[i] < epochs[j]
+	})
+
+	mergedRecords := make([]json.RawMessage, 0, len(epochs))
+	for _, epoch := range epochs {
+		mergedRecords = append(mergedRecords, recordsByEpoch[epoch])
+	}
+
+	return mergedRecords, nil
+}
+
+func getRecordEpoch(record json.RawMessage) (uint32, error) {
+	epochRecord := struct {
+		Epoch uint32 `json:"epoch"`
+	}{}
+	err := json.Unmarshal(record, &epochRecord)
+
+	return epochRecord.Epoch, err
+}
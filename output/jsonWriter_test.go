@@ -0,0 +1,46 @@
+package output
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path"
+	"testing"
+
+	"github.com/ElrondNetwork/statistics-go/data"
+)
+
+func TestJSONWriter_MergeWithExistingOutput(t *testing.T) {
+	filePath := path.Join(t.TempDir(), "output.json")
+
+	writer, _ := NewJSONWriter(true)
+	err := writer.Write(filePath, []*data.StatisticsEpoch{
+		{Epoch: 0, DailyTransactions: 1},
+		{Epoch: 1, DailyTransactions: 2},
+		{Epoch: 2, DailyTransactions: 3},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = writer.Write(filePath, []*data.StatisticsEpoch{
+		{Epoch: 2, DailyTransactions: 30},
+		{Epoch: 3, DailyTransactions: 40},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bytes, _ := ioutil.ReadFile(filePath)
+	records := make([]*data.StatisticsEpoch, 0)
+	_ = json.Unmarshal(bytes, &records)
+
+	expectedTransactions := []int{1, 2, 30, 40}
+	if len(records) != len(expectedTransactions) {
+		t.Fatalf("expected %d records, got %d", len(expectedTransactions), len(records))
+	}
+	for idx, record := range records {
+		if record.Epoch != uint32(idx) || record.DailyTransactions != expectedTransactions[idx] {
+			t.Fatalf("unexpected record %+v at index %d", record, idx)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package output
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+)
+
+type ndjsonWriter struct {
+	merge bool
+}
+
+// NewNDJSONWriter will create a new instance of ndjsonWriter
+func NewNDJSONWriter(merge bool) (*ndjsonWriter, error) {
+	return &ndjsonWriter{
+		merge: merge,
+	}, nil
+}
+
+// Write will write every statistics record as a json object on a separate line of the provided file
+func (nw *ndjsonWriter) Write(filePath string, records interface{}) error {
+	recordsBytes, err := json.Marshal(records)
+	if err != nil {
+		return err
+	}
+
+	lines := make([]json.RawMessage, 0)
+	err = json.Unmarshal(recordsBytes, &lines)
+	if err != nil {
+		return err
+	}
+
+	if nw.merge {
+		existingLines, errRead := readNDJSONLines(filePath)
+		if errRead != nil {
+			return errRead
+		}
+
+		lines, err = mergeRecords(existingLines, lines)
+		if err != nil {
+			return err
+		}
+	}
+
+	buff := &bytes.Buffer{}
+	for _, line := range lines {
+		buff.Write(line)
+		buff.WriteByte('\n')
+	}
+
+	return ioutil.WriteFile(filePath, buff.Bytes(), 0644)
+}
+
+func readNDJSONLines(filePath string) ([]json.RawMessage, error) {
+	file, err := os.Open(filePath)
+	if os.IsNotExist(err) {
+		return []json.RawMessage{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	lines := make([]json.RawMessage, 0)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		lines = append(lines, append(json.RawMessage{}, line...))
+	}
+
+	return lines, scanner.Err()
+}
@@ -0,0 +1,183 @@
+package output
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/xitongsys/parquet-go-source/local"
+	"github.com/xitongsys/parquet-go/reader"
+	"github.com/xitongsys/parquet-go/writer"
+)
+
+const parquetWriterParallelism = 4
+
+type parquetWriter struct {
+	merge bool
+}
+
+// NewParquetWriter will create a new instance of parquetWriter. Parquet files cannot be appended, so when merging
+// the existing rows are read and the whole file is written again
+func NewParquetWriter(merge bool) (*parquetWriter, error) {
+	return &parquetWriter{
+		merge: merge,
+	}, nil
+}
+
+// Write will write the flattened statistics records in the provided file and the side tables next to it
+func (pw *parquetWriter) Write(filePath string, records interface{}) error {
+	mainTable, sideTables, err := flattenRecords(records)
+	if err != nil {
+		return err
+	}
+
+	writtenEpochs := mainTable.epochs()
+	for _, t := range append([]*table{mainTable}, sideTables...) {
+		err = pw.writeTable(tableFilePath(filePath, t.name), t, writtenEpochs)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (pw *parquetWriter) writeTable(filePath string, t *table, writtenEpochs map[string]struct{}) error {
+	rows := t.rows
+	if pw.merge {
+		existingRows, err := readParquetRows(filePath)
+		if err != nil {
+			return err
+		}
+
+		rows, err = mergeRows(existingRows, rows, writtenEpochs)
+		if err != nil {
+			return err
+		}
+		padRows(rows, len(t.columns))
+	}
+
+	file, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	csvWriter, err := writer.NewCSVWriterFromWriter(parquetSchema(t), file, parquetWriterParallelism)
+	if err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		values := make([]*string, len(row))
+		for idx := range row {
+			// the padded values of the numeric columns are written as nulls
+			if row[idx] == "" && t.columns[idx].columnType != columnString {
+				continue
+			}
+			values[idx] = &row[idx]
+		}
+
+		err = csvWriter.WriteString(values)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = csvWriter.WriteStop()
+	if err != nil {
+		return err
+	}
+
+	return file.Close()
+}
+
+// readParquetRows returns the rows of an existing parquet file with the values formatted as in the flattened tables
+func readParquetRows(filePath string) ([][]string, error) {
+	_, err := os.Stat(filePath)
+	if os.IsNotExist(err) {
+		return [][]string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := local.NewLocalFileReader(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	columnReader, err := reader.NewParquetColumnReader(file, parquetWriterParallelism)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read existing output file %s: %w", filePath, err)
+	}
+	defer columnReader.ReadStop()
+
+	numRows := columnReader.GetNumRows()
+	numColumns := len(columnReader.SchemaHandler.ValueColumns)
+	rows := make([][]string, numRows)
+	for idx := range rows {
+		rows[idx] = make([]string, numColumns)
+	}
+
+	for columnIdx := 0; columnIdx < numColumns; columnIdx++ {
+		values, _, _, err := columnReader.ReadColumnByIndex(int64(columnIdx), numRows)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read existing output file %s: %w", filePath, err)
+		}
+		if int64(len(values)) != numRows {
+			return nil, fmt.Errorf("cannot read existing output file %s: column %d has %d values instead of %d",
+				filePath, columnIdx, len(values), numRows)
+		}
+
+		for rowIdx, value := range values {
+			rows[rowIdx][columnIdx] = parquetValueToString(value)
+		}
+	}
+
+	return rows, nil
+}
+
+func parquetValueToString(value interface{}) string {
+	switch typedValue := value.(type) {
+	case nil:
+		return ""
+	case int64:
+		return strconv.FormatInt(typedValue, 10)
+	case float64:
+		return floatToString(typedValue)
+	case string:
+		return typedValue
+	default:
+		return fmt.Sprintf("%v", typedValue)
+	}
+}
+
+// parquetSchema returns the metadata of the table columns, the big integer amounts are kept as strings. The numeric
+// columns after the epoch are optional, so the rows merged from an output written before a column was added have
+// nulls in it
+func parquetSchema(t *table) []string {
+	schema := make([]string, len(t.columns))
+	for idx, col := range t.columns {
+		repetition := "OPTIONAL"
+		if idx == 0 {
+			repetition = "REQUIRED"
+		}
+
+		switch col.columnType {
+		case columnInt:
+			schema[idx] = fmt.Sprintf("name=%s, type=INT64, repetitiontype=%s", col.name, repetition)
+		case columnFloat:
+			schema[idx] = fmt.Sprintf("name=%s, type=DOUBLE, repetitiontype=%s", col.name, repetition)
+		default:
+			schema[idx] = fmt.Sprintf("name=%s, type=BYTE_ARRAY, convertedtype=UTF8", col.name)
+		}
+	}
+
+	return schema
+}
@@ -0,0 +1,91 @@
+package output
+
+import (
+	"io/ioutil"
+	"path"
+	"reflect"
+	"testing"
+
+	"github.com/ElrondNetwork/statistics-go/data"
+)
+
+func TestParquetWriter_Write(t *testing.T) {
+	filePath := path.Join(t.TempDir(), "output.parquet")
+
+	writer, _ := NewParquetWriter(false)
+	err := writer.Write(filePath, []*data.StakeInfoEpoch{
+		{Epoch: 0, TotalStaked: "1000000000000000000000000000", StakingUsers: 3},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bytes, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bytes) < 8 || string(bytes[:4]) != "PAR1" || string(bytes[len(bytes)-4:]) != "PAR1" {
+		t.Fatal("output is not a parquet file")
+	}
+}
+
+func TestParquetWriter_MergeWithExistingOutput(t *testing.T) {
+	filePath := path.Join(t.TempDir(), "output.parquet")
+
+	writer, _ := NewParquetWriter(true)
+	err := writer.Write(filePath, []*data.StakeInfoEpoch{
+		{Epoch: 1, TotalStaked: "10", StakingUsers: 1},
+		{Epoch: 2, TotalStaked: "20", StakingUsers: 2, Status: data.EpochStatusFailed},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = writer.Write(filePath, []*data.StakeInfoEpoch{
+		{Epoch: 0, TotalStaked: "5", StakingUsers: 1},
+		{Epoch: 2, TotalStaked: "25", StakingUsers: 3},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := readParquetRows(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedMain, _, _ := flattenRecords([]*data.StakeInfoEpoch{
+		{Epoch: 0, TotalStaked: "5", StakingUsers: 1},
+		{Epoch: 1, TotalStaked: "10", StakingUsers: 1},
+		{Epoch: 2, TotalStaked: "25", StakingUsers: 3},
+	})
+	if !reflect.DeepEqual(rows, expectedMain.rows) {
+		t.Fatalf("unexpected merged rows %v", rows)
+	}
+}
+
+func TestParquetWriter_MergeWithOutputWithoutTheNewColumns(t *testing.T) {
+	filePath := path.Join(t.TempDir(), "output.parquet")
+
+	writer, _ := NewParquetWriter(true)
+	oldTable := &table{
+		columns: []column{{"epoch", columnInt}, {"totalStaked", columnString}},
+		rows:    [][]string{{"0", "5"}},
+	}
+	err := writer.writeTable(filePath, oldTable, oldTable.epochs())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = writer.Write(filePath, []*data.StakeInfoEpoch{{Epoch: 1, TotalStaked: "10", StakingUsers: 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := readParquetRows(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || rows[0][1] != "5" || rows[0][2] != "" || rows[1][0] != "1" {
+		t.Fatalf("unexpected merged rows %v", rows)
+	}
+}
@@ -0,0 +1,238 @@
+package output
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/ElrondNetwork/statistics-go/data"
+)
+
+type sqliteSink struct {
+	db *sql.DB
+}
+
+// NewSQLiteSink will create a new instance of sqliteSink that upserts the statistics records in a table for every
+// statistic, keyed by epoch, and replaces the rows of the nested lists in child tables
+func NewSQLiteSink(db *sql.DB) (*sqliteSink, error) {
+	if db == nil {
+		return nil, fmt.Errorf("nil database")
+	}
+
+	return &sqliteSink{
+		db: db,
+	}, nil
+}
+
+// Publish will upsert the statistics records and their nested lists
+func (ss *sqliteSink) Publish(ctx context.Context, records interface{}) error {
+	family, err := recordsFamily(records)
+	if err != nil {
+		return err
+	}
+
+	main, sideTables, err := flattenRecords(records)
+	if err != nil {
+		return err
+	}
+
+	tx, err := ss.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	err = upsertMainTable(tx, family+"_statistics", main)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	epochs := main.epochs()
+	for _, side := range sideTables {
+		err = replaceSideTable(tx, family+"_"+toSnakeCase(side.name), side, epochs)
+		if err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func upsertMainTable(tx *sql.Tx, tableName string, t *table) error {
+	err := createTable(tx, tableName, t, "PRIMARY KEY (epoch)")
+	if err != nil {
+		return err
+	}
+
+	columns := sqlColumnNames(t)
+	updates := make([]string, 0, len(columns)-1)
+	for _, columnName := range columns[1:] {
+		updates = append(updates, fmt.Sprintf("%s = excluded.%s", columnName, columnName))
+	}
+
+	statement := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s) ON CONFLICT (epoch) DO UPDATE SET %s",
+		tableName, strings.Join(columns, ", "), placeholders(len(columns)), strings.Join(updates, ", "))
+
+	return insertRows(tx, statement, t.rows)
+}
+
+// replaceSideTable deletes the rows of the published epochs before inserting the new ones, so an epoch that has
+// fewer top addresses or buckets than before does not keep stale rows
+func replaceSideTable(tx *sql.Tx, tableName string, t *table, epochs map[string]struct{}) error {
+	err := createTable(tx, tableName, t, "")
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s_epoch ON %s (epoch)", tableName, tableName))
+	if err != nil {
+		return err
+	}
+
+	for epoch := range epochs {
+		_, err = tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE epoch = ?", tableName), epoch)
+		if err != nil {
+			return err
+		}
+	}
+
+	columns := sqlColumnNames(t)
+	statement := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", tableName, strings.Join(columns, ", "), placeholders(len(columns)))
+
+	return insertRows(tx, statement, t.rows)
+}
+
+func createTable(tx *sql.Tx, tableName string, t *table, constraint string) error {
+	definitions := make([]string, 0, len(t.columns)+1)
+	for _, col := range t.columns {
+		definitions = append(definitions, fmt.Sprintf("%s %s", toSnakeCase(col.name), sqlColumnType(col.columnType)))
+	}
+	if constraint != "" {
+		definitions = append(definitions, constraint)
+	}
+
+	_, err := tx.Exec(fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s)", tableName, strings.Join(definitions, ", ")))
+	if err != nil {
+		return err
+	}
+
+	return addMissingColumns(tx, tableName, t)
+}
+
+// addMissingColumns adds the columns of the table that were introduced after the database table was created
+func addMissingColumns(tx *sql.Tx, tableName string, t *table) error {
+	rows, err := tx.Query(fmt.Sprintf("SELECT name FROM pragma_table_info('%s')", tableName))
+	if err != nil {
+		return err
+	}
+
+	existing := make(map[string]struct{})
+	for rows.Next() {
+		var name string
+		err = rows.Scan(&name)
+		if err != nil {
+			_ = rows.Close()
+			return err
+		}
+		existing[name] = struct{}{}
+	}
+	err = rows.Close()
+	if err != nil {
+		return err
+	}
+
+	for _, col := range t.columns {
+		columnName := toSnakeCase(col.name)
+		if _, ok := existing[columnName]; ok {
+			continue
+		}
+
+		_, err = tx.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", tableName, columnName, sqlColumnType(col.columnType)))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func insertRows(tx *sql.Tx, statement string, rows [][]string) error {
+	stmt, err := tx.Prepare(statement)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = stmt.Close()
+	}()
+
+	for _, row := range rows {
+		values := make([]interface{}, len(row))
+		for idx, value := range row {
+			values[idx] = value
+		}
+
+		_, err = stmt.Exec(values...)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func recordsFamily(records interface{}) (string, error) {
+	switch records.(type) {
+	case []*data.StatisticsEpoch:
+		return "transactions", nil
+	case []*data.StatisticsAddressesBalanceEpoch:
+		return "accounts", nil
+	case []*data.StakeInfoEpoch:
+		return "stake", nil
+	default:
+		return "", fmt.Errorf("cannot store records of type %T", records)
+	}
+}
+
+func sqlColumnNames(t *table) []string {
+	names := make([]string, len(t.columns))
+	for idx, col := range t.columns {
+		names[idx] = toSnakeCase(col.name)
+	}
+
+	return names
+}
+
+// sqlColumnType keeps the big integer amounts as text so they are not truncated
+func sqlColumnType(columnType string) string {
+	switch columnType {
+	case columnInt:
+		return "INTEGER"
+	case columnFloat:
+		return "REAL"
+	default:
+		return "TEXT"
+	}
+}
+
+func placeholders(count int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", count), ", ")
+}
+
+// toSnakeCase converts the column names of the flat tables, e.g. topActiveAccounts becomes top_active_accounts
+func toSnakeCase(name string) string {
+	builder := strings.Builder{}
+	for idx, r := range name {
+		if unicode.IsUpper(r) {
+			if idx > 0 {
+				builder.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		builder.WriteRune(r)
+	}
+
+	return builder.String()
+}
@@ -0,0 +1,96 @@
+package output
+
+import (
+	"context"
+	"path"
+	"testing"
+
+	"github.com/ElrondNetwork/statistics-go/data"
+	"github.com/ElrondNetwork/statistics-go/sqlite"
+)
+
+func TestSQLiteSink_PublishUpserts(t *testing.T) {
+	db, err := sqlite.OpenDatabase(path.Join(t.TempDir(), "statistics.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	sink, _ := NewSQLiteSink(db)
+
+	err = sink.Publish(context.Background(), []*data.StatisticsEpoch{
+		{Epoch: 1, DailyTransactions: 10, TopActiveAddresses: []*data.AddressCount{{Address: "a", Count: 3}, {Address: "b", Count: 2}}},
+		{Epoch: 2, DailyTransactions: 20},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = sink.Publish(context.Background(), []*data.StatisticsEpoch{
+		{Epoch: 1, DailyTransactions: 15, TopActiveAddresses: []*data.AddressCount{{Address: "c", Count: 5}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var count, dailyTransactions int
+	err = db.QueryRow("SELECT COUNT(*), SUM(daily_transactions) FROM transactions_statistics").Scan(&count, &dailyTransactions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 || dailyTransactions != 35 {
+		t.Fatalf("expected 2 epochs with 35 transactions, got %d epochs with %d transactions", count, dailyTransactions)
+	}
+
+	var address string
+	err = db.QueryRow("SELECT COUNT(*), MAX(address) FROM transactions_top_active_accounts WHERE epoch = 1").Scan(&count, &address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 || address != "c" {
+		t.Fatalf("expected the top addresses of epoch 1 to be replaced, got %d rows, address %s", count, address)
+	}
+}
+
+func TestSQLiteSink_AddsTheNewColumnsToExistingTables(t *testing.T) {
+	db, err := sqlite.OpenDatabase(path.Join(t.TempDir(), "statistics.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	_, err = db.Exec("CREATE TABLE stake_statistics (epoch INTEGER, total_staked TEXT, PRIMARY KEY (epoch))")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sink, _ := NewSQLiteSink(db)
+	err = sink.Publish(context.Background(), []*data.StakeInfoEpoch{
+		{Epoch: 3, TotalStaked: "100", Status: data.EpochStatusFailed, Error: "timeout"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var status, errorMessage string
+	err = db.QueryRow("SELECT status, error FROM stake_statistics WHERE epoch = 3").Scan(&status, &errorMessage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status != data.EpochStatusFailed || errorMessage != "timeout" {
+		t.Fatalf("unexpected status %s and error %s", status, errorMessage)
+	}
+}
+
+func TestToSnakeCase(t *testing.T) {
+	if name := toSnakeCase("top10Share"); name != "top10_share" {
+		t.Fatalf("unexpected name %s", name)
+	}
+	if name := toSnakeCase("epoch"); name != "epoch" {
+		t.Fatalf("unexpected name %s", name)
+	}
+}
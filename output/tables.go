@@ -0,0 +1,233 @@
+package output
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/ElrondNetwork/statistics-go/data"
+)
+
+const (
+	columnInt    = "int"
+	columnFloat  = "float"
+	columnString = "string"
+)
+
+type column struct {
+	name       string
+	columnType string
+}
+
+// table is the flat representation of statistics records, the first column of every table is the epoch
+type table struct {
+	name    string
+	columns []column
+	rows    [][]string
+}
+
+// flattenRecords converts the statistics records in a main table and side tables for the nested lists
+func flattenRecords(records interface{}) (*table, []*table, error) {
+	switch typedRecords := records.(type) {
+	case []*data.StatisticsEpoch:
+		return flattenTransactionsStatistics(typedRecords)
+	case []*data.StatisticsAddressesBalanceEpoch:
+		return flattenAccountsStatistics(typedRecords)
+	case []*data.StakeInfoEpoch:
+		return flattenStakeStatistics(typedRecords)
+	default:
+		return nil, nil, fmt.Errorf("cannot flatten records of type %T", records)
+	}
+}
+
+func flattenTransactionsStatistics(records []*data.StatisticsEpoch) (*table, []*table, error) {
+	main := &table{
+		columns: []column{
+			{"epoch", columnInt},
+			{"dailyTransactions", columnInt},
+			{"dailyContractCalls", columnInt},
+			{"dailyActiveAccounts", columnInt},
+			{"dailyActiveContractAccounts", columnInt},
+			{"dailyNewAddresses", columnInt},
+			{"dailyNewContractAddresses", columnInt},
+			{"status", columnString},
+			{"error", columnString},
+		},
+	}
+	topAccounts := newTopAddressesTable("topActiveAccounts")
+	topContracts := newTopAddressesTable("topActiveContracts")
+
+	for _, record := range records {
+		main.rows = append(main.rows, []string{
+			uintToString(record.Epoch),
+			strconv.Itoa(record.DailyTransactions),
+			strconv.Itoa(record.DailyContractCalls),
+			strconv.Itoa(record.DailyActiveAccounts),
+			strconv.Itoa(record.DailyActiveContractAccounts),
+			strconv.Itoa(record.DailyNewAddresses),
+			strconv.Itoa(record.DailyNewContractAddresses),
+			record.Status,
+			record.Error,
+		})
+		topAccounts.addTopAddresses(record.Epoch, record.TopActiveAddresses)
+		topContracts.addTopAddresses(record.Epoch, record.TopActiveContracts)
+	}
+
+	return main, []*table{topAccounts, topContracts}, nil
+}
+
+func newTopAddressesTable(name string) *table {
+	return &table{
+		name: name,
+		columns: []column{
+			{"epoch", columnInt},
+			{"rank", columnInt},
+			{"address", columnString},
+			{"count", columnInt},
+		},
+	}
+}
+
+func (t *table) addTopAddresses(epoch uint32, topAddresses []*data.AddressCount) {
+	for idx, addressCount := range topAddresses {
+		t.rows = append(t.rows, []string{
+			uintToString(epoch),
+			strconv.Itoa(idx + 1),
+			addressCount.Address,
+			strconv.Itoa(addressCount.Count),
+		})
+	}
+}
+
+func flattenAccountsStatistics(records []*data.StatisticsAddressesBalanceEpoch) (*table, []*table, error) {
+	main := &table{
+		columns: []column{
+			{"epoch", columnInt},
+			{"totalAddresses", columnInt},
+			{"totalContractAddresses", columnInt},
+			{"excludedAddresses", columnInt},
+			{"nonZero", columnInt},
+			{"totalBalance", columnString},
+			{"medianBalance", columnString},
+			{"p90Balance", columnString},
+			{"p99Balance", columnString},
+			{"p999Balance", columnString},
+			{"giniCoefficient", columnFloat},
+			{"nakamotoCoefficient", columnInt},
+			{"top10Share", columnFloat},
+			{"top100Share", columnFloat},
+			{"top1000Share", columnFloat},
+			{"status", columnString},
+			{"error", columnString},
+		},
+	}
+	distribution := &table{
+		name: "balanceDistribution",
+		columns: []column{
+			{"epoch", columnInt},
+			{"threshold", columnString},
+			{"count", columnInt},
+		},
+	}
+
+	for _, record := range records {
+		concentration := record.WealthConcentration
+		if concentration == nil {
+			concentration = &data.WealthConcentration{}
+		}
+
+		main.rows = append(main.rows, []string{
+			uintToString(record.Epoch),
+			strconv.Itoa(record.TotalAddresses),
+			strconv.Itoa(record.TotalContractAddresses),
+			strconv.Itoa(record.ExcludedAddresses),
+			strconv.Itoa(record.NonZero),
+			concentration.TotalBalance,
+			concentration.MedianBalance,
+			concentration.P90Balance,
+			concentration.P99Balance,
+			concentration.P999Balance,
+			floatToString(concentration.GiniCoefficient),
+			strconv.Itoa(concentration.NakamotoCoefficient),
+			floatToString(concentration.Top10Share),
+			floatToString(concentration.Top100Share),
+			floatToString(concentration.Top1000Share),
+			record.Status,
+			record.Error,
+		})
+
+		for _, bucket := range record.BalanceDistribution {
+			distribution.rows = append(distribution.rows, []string{
+				uintToString(record.Epoch),
+				bucket.Threshold,
+				strconv.Itoa(bucket.Count),
+			})
+		}
+	}
+
+	return main, []*table{distribution}, nil
+}
+
+func flattenStakeStatistics(records []*data.StakeInfoEpoch) (*table, []*table, error) {
+	main := &table{
+		columns: []column{
+			{"epoch", columnInt},
+			{"totalStaked", columnString},
+			{"legacyDelegationUsers", columnInt},
+			{"legacyDelegationWaitingList", columnString},
+			{"totalDelegatedLegacy", columnString},
+			{"stakingUsers", columnInt},
+			{"staking", columnString},
+			{"totalUniqueUsers", columnInt},
+			{"delegationUsers", columnInt},
+			{"delegation", columnString},
+			{"status", columnString},
+			{"error", columnString},
+		},
+	}
+
+	for _, record := range records {
+		main.rows = append(main.rows, []string{
+			uintToString(record.Epoch),
+			record.TotalStaked,
+			strconv.Itoa(record.LegacyDelegationUser),
+			record.LegacyDelegation,
+			record.TotalDelegatedLegacy,
+			strconv.Itoa(record.StakingUsers),
+			record.Staking,
+			strconv.Itoa(record.TotalUniqueUsers),
+			strconv.Itoa(record.DelegationUsers),
+			record.Delegation,
+			record.Status,
+			record.Error,
+		})
+	}
+
+	return main, []*table{}, nil
+}
+
+func (t *table) columnNames() []string {
+	names := make([]string, len(t.columns))
+	for idx, col := range t.columns {
+		names[idx] = col.name
+	}
+
+	return names
+}
+
+// epochs returns the set of epochs present in the table rows
+func (t *table) epochs() map[string]struct{} {
+	epochs := make(map[string]struct{}, len(t.rows))
+	for _, row := range t.rows {
+		epochs[row[0]] = struct{}{}
+	}
+
+	return epochs
+}
+
+func uintToString(value uint32) string {
+	return strconv.FormatUint(uint64(value), 10)
+}
+
+func floatToString(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
@@ -1,9 +1,13 @@
 package process
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"math/big"
+	"strings"
 	"time"
 
 	dataIndexer "github.com/ElrondNetwork/elastic-indexer-go/data"
@@ -11,75 +15,279 @@ import (
 	"github.com/ElrondNetwork/statistics-go/data"
 )
 
-var (
-	nonZero     = big.NewInt(0)
-	b01EGLD, _  = big.NewInt(0).SetString("100000000000000000", 10)
-	b1EGLD, _   = big.NewInt(0).SetString("1000000000000000000", 10)
-	b10EGLD, _  = big.NewInt(0).SetString("10000000000000000000", 10)
-	b100EGLD, _ = big.NewInt(0).SetString("100000000000000000000", 10)
-	b1KEGLD, _  = big.NewInt(0).SetString("1000000000000000000000", 10)
-)
+var zero = big.NewInt(0)
+
+const accountsCheckpointName = "accounts"
 
 type accountInfo struct {
-	balance   *big.Int
-	timestamp time.Duration
+	Balance   *big.Int      `json:"balance"`
+	Timestamp time.Duration `json:"timestamp"`
+}
+
+// accountsProcessorState holds everything the accounts processor accumulates across epochs
+type accountsProcessorState struct {
+	LastEpoch     uint32                  `json:"lastEpoch"`
+	TotalContract int                     `json:"totalContract"`
+	Accounts      map[string]*accountInfo `json:"accounts"`
 }
 
 type accountsProcessor struct {
-	elasticHandler  ElasticHandler
-	stats           map[uint32]*data.StatisticsAddressesBalanceEpoch
-	accounts        map[string]*accountInfo
-	epoch           uint32
-	totalContract   int
-	pubKeyConverter core.PubkeyConverter
-	genesisTime     int
+	elasticHandler      ElasticHandler
+	stateStorer         StateStorer
+	stakeBalances       StakeBalancesHandler
+	exclusion           AddressesExclusionHandler
+	buckets             BalanceBucketsHandler
+	stats               map[uint32]*data.StatisticsAddressesBalanceEpoch
+	accounts            map[string]*accountInfo
+	epoch               uint32
+	totalContract       int
+	pubKeyConverter     core.PubkeyConverter
+	epochBoundaries     EpochBoundariesHandler
+	checkpointsInterval uint32
+	epochMetrics        EpochMetricsHandler
+	indices             Indices
+	strict              bool
+
+	// the accounts before the processed epoch, nil for the new ones, restore the state when the epoch is discarded
+	previousAccounts      map[string]*accountInfo
+	previousTotalContract int
 }
 
 func NewAccountsProcessor(
 	elasticHandler ElasticHandler,
+	stateStorer StateStorer,
+	stakeBalances StakeBalancesHandler,
+	exclusion AddressesExclusionHandler,
+	buckets BalanceBucketsHandler,
 	pubKeyConverter core.PubkeyConverter,
-	genesisTime int,
+	epochBoundaries EpochBoundariesHandler,
+	checkpointsInterval uint32,
+	epochMetrics EpochMetricsHandler,
+	indices Indices,
+	strict bool,
 ) (*accountsProcessor, error) {
+	if epochMetrics == nil {
+		return nil, fmt.Errorf("nil epoch metrics handler")
+	}
+
 	return &accountsProcessor{
-		elasticHandler:  elasticHandler,
-		pubKeyConverter: pubKeyConverter,
-		stats:           map[uint32]*data.StatisticsAddressesBalanceEpoch{},
-		accounts:        map[string]*accountInfo{},
-		genesisTime:     genesisTime,
+		elasticHandler:      elasticHandler,
+		stateStorer:         stateStorer,
+		stakeBalances:       stakeBalances,
+		exclusion:           exclusion,
+		buckets:             buckets,
+		pubKeyConverter:     pubKeyConverter,
+		stats:               map[uint32]*data.StatisticsAddressesBalanceEpoch{},
+		accounts:            map[string]*accountInfo{},
+		epochBoundaries:     epochBoundaries,
+		checkpointsInterval: checkpointsInterval,
+		epochMetrics:        epochMetrics,
+		indices:             indices,
+		strict:              strict,
 	}, nil
 }
 
-func (ap *accountsProcessor) ProcessAllAccounts(endEpoch uint32) ([]byte, error) {
-	for epoch := uint32(0); epoch < endEpoch; epoch++ {
+func (ap *accountsProcessor) ProcessAllAccounts(ctx context.Context, startEpoch, endEpoch uint32, resume bool) ([]*data.StatisticsAddressesBalanceEpoch, error) {
+	firstEpoch, err := ap.loadCheckpoint(startEpoch, resume)
+	if err != nil {
+		return nil, err
+	}
+	if resume {
+		startEpoch = firstEpoch
+	}
+
+	err = ap.checkStakeBalances(firstEpoch, endEpoch)
+	if err != nil {
+		return nil, err
+	}
+
+	sliceStats := make([]*data.StatisticsAddressesBalanceEpoch, 0)
+	unsaved := false
+	for epoch := firstEpoch; epoch < endEpoch; epoch++ {
 		log.Printf("process accounts history epoch %d \n", epoch)
 
 		ap.epoch = epoch
-		ap.stats[ap.epoch] = &data.StatisticsAddressesBalanceEpoch{}
+		ap.stats[ap.epoch] = &data.StatisticsAddressesBalanceEpoch{
+			Epoch: epoch,
+		}
+		ap.startEpochChanges()
 
-		err := ap.processAccountsEpoch(ap.genesisTime+int(epoch)*secondsInADay, ap.genesisTime+int(epoch+1)*secondsInADay)
+		epochStart := time.Now()
+		err = processWithWindowRestarts(ctx, epoch, ap.processEpoch, ap.restoreStateBefore)
+		if ctx.Err() != nil {
+			// the interrupted epoch is not saved, a run with resume continues with it
+			return sliceStats, ap.saveBeforeStop(epoch, unsaved, ctx.Err())
+		}
+		if epoch >= startEpoch {
+			ap.epochMetrics.EpochProcessed(StatisticAccounts, time.Since(epochStart), err != nil)
+		}
+		if isHardError(err) || (err != nil && ap.strict) {
+			return sliceStats, ap.saveBeforeStop(epoch, unsaved, fmt.Errorf("process accounts epoch %d: %w", epoch, err))
+		}
 		if err != nil {
 			log.Printf("cannot proccess accouts for epoch %d, error %s", epoch, err.Error())
-			continue
 		}
+		ap.stats[epoch].Status, ap.stats[epoch].Error = data.EpochStatus(err)
+
+		unsaved = true
+		if isCheckpointEpoch(epoch, endEpoch, ap.checkpointsInterval) {
+			err = ap.saveCheckpoint(epoch)
+			if err != nil {
+				return nil, err
+			}
+			unsaved = false
+		}
+		ap.stakeBalances.ReleaseStakeBalances(epoch)
+
+		// epochs before the start epoch are only replayed to rebuild the state needed by the cumulative metrics
+		if epoch >= startEpoch {
+			sliceStats = append(sliceStats, ap.stats[ap.epoch])
+		}
+	}
+
+	return sliceStats, nil
+}
+
+func (ap *accountsProcessor) processEpoch(ctx context.Context) error {
+	start, stop, err := ap.epochBoundaries.EpochBoundaries(ctx, ap.epoch)
+	if err != nil {
+		return err
+	}
+
+	err = ap.processAccountsEpoch(ctx, start, stop)
+	if err != nil {
+		return err
+	}
 
-		ap.setCounts()
-		ap.stats[ap.epoch].TotalAddresses = len(ap.accounts)
-		ap.stats[ap.epoch].TotalContractAddresses = ap.totalContract
+	err = ap.setCounts()
+	if err != nil {
+		return err
 	}
+	ap.stats[ap.epoch].TotalAddresses = len(ap.accounts)
+	ap.stats[ap.epoch].TotalContractAddresses = ap.totalContract
+
+	return nil
+}
 
-	sliceStats := make([]*data.StatisticsAddressesBalanceEpoch, endEpoch)
-	for idx := uint32(0); idx < endEpoch; idx++ {
-		ap.stats[idx].Epoch = idx
-		sliceStats[idx] = ap.stats[idx]
+func (ap *accountsProcessor) saveCheckpoint(epoch uint32) error {
+	state := &accountsProcessorState{
+		LastEpoch:     epoch,
+		TotalContract: ap.totalContract,
+		Accounts:      ap.accounts,
 	}
 
-	bytes, _ := json.MarshalIndent(sliceStats, "", " ")
+	return ap.stateStorer.SaveCheckpoint(accountsCheckpointName, epoch, state)
+}
+
+// saveBeforeStop saves the state of the epochs completed since the last checkpoint when the run stops at the provided
+// epoch, after discarding what was gathered for it. The returned error is the one that stopped the run
+func (ap *accountsProcessor) saveBeforeStop(epoch uint32, unsaved bool, errStop error) error {
+	if !unsaved {
+		return errStop
+	}
+
+	err := ap.restoreStateBefore(epoch)
+	if err == nil {
+		err = ap.saveCheckpoint(epoch - 1)
+	}
+	if err != nil {
+		log.Printf("cannot save the accounts checkpoint of epoch %d, error %s", epoch-1, err.Error())
+	}
 
-	return bytes, nil
+	return errStop
 }
 
-func (ap *accountsProcessor) processAccountsEpoch(start, stop int) error {
-	err := ap.elasticHandler.DoScrollRequestAllDocuments(getTransactionsByTimestamp(start, stop), "accountshistory", ap.processAccountsHistoryResponse)
+// loadCheckpoint restores the state needed before processing the start epoch and returns the first epoch
+// that has to be processed. Without a suitable checkpoint, the processing has to start from the first epoch
+func (ap *accountsProcessor) loadCheckpoint(startEpoch uint32, resume bool) (uint32, error) {
+	checkpointEpoch, found, err := checkpointToLoad(ap.stateStorer, accountsCheckpointName, startEpoch, resume)
+	if err != nil {
+		return 0, err
+	}
+	if !found {
+		ap.resetState()
+		return 0, nil
+	}
+
+	err = ap.restoreCheckpoint(checkpointEpoch)
+	if err != nil {
+		return 0, err
+	}
+
+	log.Printf("continue accounts processing from checkpoint of epoch %d \n", checkpointEpoch)
+
+	return checkpointEpoch + 1, nil
+}
+
+func (ap *accountsProcessor) restoreCheckpoint(checkpointEpoch uint32) error {
+	state := &accountsProcessorState{}
+	err := ap.stateStorer.LoadCheckpoint(accountsCheckpointName, checkpointEpoch, state)
+	if err != nil {
+		return err
+	}
+
+	ap.totalContract = state.TotalContract
+	ap.accounts = state.Accounts
+	if ap.accounts == nil {
+		ap.accounts = map[string]*accountInfo{}
+	}
+
+	return nil
+}
+
+// resetState sets the state from before the first epoch, when no account was seen yet
+func (ap *accountsProcessor) resetState() {
+	ap.totalContract = 0
+	ap.accounts = map[string]*accountInfo{}
+}
+
+// startEpochChanges starts recording the accounts changed by the processed epoch
+func (ap *accountsProcessor) startEpochChanges() {
+	ap.previousAccounts = map[string]*accountInfo{}
+	ap.previousTotalContract = ap.totalContract
+}
+
+// keepPreviousAccount records the account as it was before the processed epoch, the first time the epoch changes it
+func (ap *accountsProcessor) keepPreviousAccount(address string) {
+	_, kept := ap.previousAccounts[address]
+	if kept {
+		return
+	}
+
+	info, ok := ap.accounts[address]
+	if !ok {
+		ap.previousAccounts[address] = nil
+		return
+	}
+
+	ap.previousAccounts[address] = &accountInfo{
+		Balance:   big.NewInt(0).Set(info.Balance),
+		Timestamp: info.Timestamp,
+	}
+}
+
+// restoreStateBefore discards everything gathered while processing the provided epoch, the changed accounts get
+// back the values they had before it
+func (ap *accountsProcessor) restoreStateBefore(epoch uint32) error {
+	ap.stats[epoch] = &data.StatisticsAddressesBalanceEpoch{
+		Epoch: epoch,
+	}
+
+	for address, info := range ap.previousAccounts {
+		if info == nil {
+			delete(ap.accounts, address)
+			continue
+		}
+		ap.accounts[address] = info
+	}
+	ap.totalContract = ap.previousTotalContract
+	ap.startEpochChanges()
+
+	return nil
+}
+
+func (ap *accountsProcessor) processAccountsEpoch(ctx context.Context, start, stop int) error {
+	err := ap.elasticHandler.DoScrollRequestAllDocuments(ctx, getTransactionsByTimestamp(start, stop), ap.indices.AccountsHistory, ap.processAccountsHistoryResponse)
 	if err != nil {
 		return err
 	}
@@ -102,11 +310,13 @@ func (ap *accountsProcessor) processAccountsHistoryResponse(responseBytes []byte
 }
 
 func (ap *accountsProcessor) extractAccountInfo(acct *dataIndexer.AccountBalanceHistory) {
+	ap.keepPreviousAccount(acct.Address)
+
 	_, ok := ap.accounts[acct.Address]
 	if !ok {
 		ap.accounts[acct.Address] = &accountInfo{
-			balance:   stringToBigInt(acct.Balance),
-			timestamp: acct.Timestamp,
+			Balance:   stringToBigInt(acct.Balance),
+			Timestamp: acct.Timestamp,
 		}
 	}
 
@@ -115,67 +325,78 @@ func (ap *accountsProcessor) extractAccountInfo(acct *dataIndexer.AccountBalance
 		ap.totalContract++
 	}
 
-	if ap.accounts[acct.Address].timestamp > acct.Timestamp {
+	if ap.accounts[acct.Address].Timestamp > acct.Timestamp {
 		return
 	}
 
-	ap.accounts[acct.Address].timestamp = acct.Timestamp
-	ap.accounts[acct.Address].balance = stringToBigInt(acct.Balance)
+	ap.accounts[acct.Address].Timestamp = acct.Timestamp
+	ap.accounts[acct.Address].Balance = stringToBigInt(acct.Balance)
 
 	return
 }
 
-func (ap *accountsProcessor) setCounts() {
-	currentEpochStats := ap.stats[ap.epoch]
+// checkStakeBalances verifies that the staked balances of all the epochs to process exist, since the balances of
+// the accounts without them would be wrong. In strict mode the processing does not start, otherwise the epochs
+// without staked balances are saved with the failed status
+func (ap *accountsProcessor) checkStakeBalances(firstEpoch, endEpoch uint32) error {
+	missing := make([]string, 0)
+	for epoch := firstEpoch; epoch < endEpoch; epoch++ {
+		exists, err := ap.stakeBalances.HasStakeBalances(epoch)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			missing = append(missing, fmt.Sprintf("%d", epoch))
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	message := fmt.Sprintf("no stake balances for epochs %s, the stake statistics of these epochs have to be generated first",
+		strings.Join(missing, ", "))
+	if ap.strict {
+		return errors.New(message)
+	}
+
+	log.Print(message)
 
-	var balancesStake map[string]string
-	var err error
+	return nil
+}
 
-	balancesStake, err = ReadBalances("../reportsV2/balances", ap.epoch)
+func (ap *accountsProcessor) setCounts() error {
+	currentEpochStats := ap.stats[ap.epoch]
+	currentEpochStats.BalanceDistribution = ap.buckets.NewDistribution()
+
+	balancesStake, err := ap.stakeBalances.GetStakeBalances(ap.epoch)
 	if err != nil {
-		balancesStake = map[string]string{}
+		return fmt.Errorf("cannot read the stake balances of epoch %d: %w", ap.epoch, err)
 	}
 
+	nonZeroBalances := make([]*big.Int, 0, len(ap.accounts))
 	for key, acctInfo := range ap.accounts {
-		currentBalance := big.NewInt(0).SetBytes(acctInfo.balance.Bytes())
+		if ap.exclusion.IsExcluded(key, ap.epoch) {
+			currentEpochStats.ExcludedAddresses++
+			continue
+		}
+
+		currentBalance := big.NewInt(0).SetBytes(acctInfo.Balance.Bytes())
 		_, ok := balancesStake[key]
 		if ok {
 			currentBalance.Add(currentBalance, stringToBigInt(balancesStake[key]))
 		}
 
-		if currentBalance.Cmp(nonZero) > 0 {
+		if currentBalance.Cmp(zero) > 0 {
 			currentEpochStats.NonZero++
+			nonZeroBalances = append(nonZeroBalances, currentBalance)
 		}
 
-		if currentBalance.Cmp(b01EGLD) >= 0 {
-			currentEpochStats.B01EGLD++
-		}
-
-		if currentBalance.Cmp(b1EGLD) >= 0 {
-			currentEpochStats.B1EGLD++
-		}
-
-		if currentBalance.Cmp(b10EGLD) >= 0 {
-			currentEpochStats.B10EGLD++
-		}
-
-		if currentBalance.Cmp(b100EGLD) >= 0 {
-			currentEpochStats.B100EGLD++
-		}
-
-		if currentBalance.Cmp(b1KEGLD) >= 0 {
-			currentEpochStats.B1kEGLD++
-		}
+		ap.buckets.AddBalance(currentEpochStats.BalanceDistribution, currentBalance)
 	}
 
-	if ap.epoch >= 239 {
-		currentEpochStats.NonZero = currentEpochStats.NonZero - 2250
-		currentEpochStats.B01EGLD = currentEpochStats.B01EGLD - 2250
-		currentEpochStats.B1EGLD = currentEpochStats.B1EGLD - 2550
-		currentEpochStats.B10EGLD = currentEpochStats.B10EGLD - 2000
-		currentEpochStats.B100EGLD = currentEpochStats.B100EGLD - 2100
-		currentEpochStats.B1kEGLD = currentEpochStats.B1kEGLD - 410
-	}
+	currentEpochStats.WealthConcentration = computeWealthConcentration(nonZeroBalances)
+
+	return nil
 }
 
 func stringToBigInt(b string) *big.Int {
@@ -1,22 +1,220 @@
 package process
 
 import (
+	"bytes"
+	"context"
+	"math/big"
 	"testing"
+	"time"
 
 	"github.com/ElrondNetwork/elrond-go/config"
 	"github.com/ElrondNetwork/elrond-go/data/state/factory"
+	"github.com/ElrondNetwork/statistics-go/data"
 	"github.com/ElrondNetwork/statistics-go/elasticClient"
+	"github.com/ElrondNetwork/statistics-go/retry"
+	"github.com/ElrondNetwork/statistics-go/state"
 	"github.com/elastic/go-elasticsearch/v7"
 )
 
 func TestAp(t *testing.T) {
 	pubKeyConverter, _ := factory.NewPubkeyConverter(config.PubkeyConfig{Type: "bech32", Length: 32})
 
+	retryPolicy, _ := retry.NewPolicy(1, 0, 0, nil)
 	elsaticC, _ := elasticClient.NewElasticClient(elasticsearch.Config{
 		Addresses: []string{"http://localhost:9200"},
+	}, 0, retryPolicy, 1, elasticClient.PaginationScroll)
+
+	stateStorer, _ := state.NewFileStorer(t.TempDir(), 0, 0)
+	epochBoundaries, _ := NewFixedEpochBoundaries(1596117600)
+	balancesStorer, _ := state.NewBalancesFileStorer(t.TempDir())
+	stakeBalances, _ := NewStakeBalancesHolder(balancesStorer)
+	buckets, _ := NewBalanceBuckets([]string{"0.1", "1", "10", "100", "1000"})
+	exclusion, _ := NewAddressesExclusion(nil)
+
+	ap, _ := NewAccountsProcessor(elsaticC, stateStorer, stakeBalances, exclusion, buckets, pubKeyConverter, epochBoundaries, 0, NewDisabledEpochMetrics(), testIndices, false)
+
+	ap.ProcessAllAccounts(context.Background(), 0, 50, false)
+}
+
+// emptyElasticStub returns no documents
+type emptyElasticStub struct {
+	elasticSearchStub
+}
+
+func (ees *emptyElasticStub) DoScrollRequestAllDocuments(_ context.Context, _ *bytes.Buffer, _ string, _ func(responseBytes []byte) error) error {
+	return nil
+}
+
+func newAccountsProcessorWithStakeBalances(t *testing.T, stakeEpochs []uint32, strict bool) *accountsProcessor {
+	pubKeyConverter, _ := factory.NewPubkeyConverter(config.PubkeyConfig{Type: "bech32", Length: 32})
+	stateStorer, _ := state.NewFileStorer(t.TempDir(), 0, 0)
+	epochBoundaries, _ := NewFixedEpochBoundaries(1596117600)
+	balancesStorer, _ := state.NewBalancesFileStorer(t.TempDir())
+	stakeBalances, _ := NewStakeBalancesHolder(balancesStorer)
+	for _, epoch := range stakeEpochs {
+		stakeBalances.SetStakeBalances(epoch, map[string]string{"erd1staker": "1000"})
+	}
+	buckets, _ := NewBalanceBuckets([]string{"0.1", "1"})
+	exclusion, _ := NewAddressesExclusion(nil)
+
+	ap, _ := NewAccountsProcessor(&emptyElasticStub{}, stateStorer, stakeBalances, exclusion, buckets, pubKeyConverter, epochBoundaries, 0, NewDisabledEpochMetrics(), testIndices, strict)
+
+	return ap
+}
+
+func TestAccountsProcessor_MissingStakeBalancesFailTheEpoch(t *testing.T) {
+	ap := newAccountsProcessorWithStakeBalances(t, []uint32{0, 2}, false)
+
+	records, err := ap.ProcessAllAccounts(context.Background(), 0, 3, false)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("expected 3 records, got %d", len(records))
+	}
+
+	expectedStatus := []string{data.EpochStatusOK, data.EpochStatusFailed, data.EpochStatusOK}
+	for idx, record := range records {
+		if record.Status != expectedStatus[idx] {
+			t.Fatalf("expected epoch %d with status %s, got %s (%s)", idx, expectedStatus[idx], record.Status, record.Error)
+		}
+	}
+}
+
+func TestAccountsProcessor_MissingStakeBalancesStopTheStrictRun(t *testing.T) {
+	ap := newAccountsProcessorWithStakeBalances(t, []uint32{0, 2}, true)
+
+	records, err := ap.ProcessAllAccounts(context.Background(), 0, 3, false)
+	if err == nil || len(records) != 0 {
+		t.Fatalf("expected the run to stop before processing, got %d records, error %v", len(records), err)
+	}
+}
+
+func TestAccountsProcessor_ReleasesTheUsedStakeBalances(t *testing.T) {
+	ap := newAccountsProcessorWithStakeBalances(t, []uint32{0, 1, 2, 3}, true)
+
+	_, err := ap.ProcessAllAccounts(context.Background(), 0, 3, false)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	holder := ap.stakeBalances.(*stakeBalancesHolder)
+	if len(holder.balancesByEpoch) != 1 || holder.balancesByEpoch[3] == nil {
+		t.Fatalf("expected only the stake balances of epoch 3 in memory, got %d epochs", len(holder.balancesByEpoch))
+	}
+
+	balances, err := holder.GetStakeBalances(0)
+	if err != nil || balances["erd1staker"] != "1000" {
+		t.Fatalf("expected the released stake balances from the storer, got %v, error %v", balances, err)
+	}
+}
+
+// epochMetricsStub records the failed flag of every recorded epoch
+type epochMetricsStub struct {
+	failed []bool
+}
+
+func (ems *epochMetricsStub) EpochProcessed(_ string, _ time.Duration, failed bool) {
+	ems.failed = append(ems.failed, failed)
+}
+
+func TestAccountsProcessor_RecordsTheMetricsOfTheRequestedEpochs(t *testing.T) {
+	ap := newAccountsProcessorWithStakeBalances(t, []uint32{0, 2}, false)
+	metrics := &epochMetricsStub{}
+	ap.epochMetrics = metrics
+
+	_, err := ap.ProcessAllAccounts(context.Background(), 1, 3, false)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	// epoch 0 is only replayed, epoch 1 has no stake balances
+	if len(metrics.failed) != 2 || !metrics.failed[0] || metrics.failed[1] {
+		t.Fatalf("expected the failed epoch 1 and the processed epoch 2, got %v", metrics.failed)
+	}
+}
+
+// pagesElasticStub passes the next page on every scroll request and loses the provided scroll requests after the
+// page was handled
+type pagesElasticStub struct {
+	elasticSearchStub
+	pages    []string
+	expireAt map[int]bool
+	scrolls  int
+}
+
+func (pes *pagesElasticStub) DoScrollRequestAllDocuments(_ context.Context, _ *bytes.Buffer, _ string, handlerFunc func(responseBytes []byte) error) error {
+	err := handlerFunc([]byte(pes.pages[pes.scrolls]))
+	pes.scrolls++
+	if err != nil {
+		return err
+	}
+	if pes.expireAt[pes.scrolls] {
+		return data.ErrScrollExpired
+	}
+
+	return nil
+}
+
+func TestAccountsProcessor_ExpiredScrollDiscardsTheChangesOfTheEpoch(t *testing.T) {
+	ap := newAccountsProcessorWithStakeBalances(t, []uint32{0, 1}, true)
+	ap.checkpointsInterval = 10
+	ap.elasticHandler = &pagesElasticStub{
+		pages: []string{
+			`{"hits":{"hits":[{"_source":{"address":"erd1first","balance":"1","timestamp":1}}]}}`,
+			`{"hits":{"hits":[{"_source":{"address":"erd1first","balance":"7","timestamp":2}},{"_source":{"address":"erd1lost","balance":"3","timestamp":2}}]}}`,
+			`{"hits":{"hits":[{"_source":{"address":"erd1second","balance":"3","timestamp":2}}]}}`,
+		},
+		expireAt: map[int]bool{2: true},
+	}
+
+	records, err := ap.ProcessAllAccounts(context.Background(), 0, 2, false)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if len(records) != 2 || records[1].TotalAddresses != 2 {
+		t.Fatalf("expected 2 addresses after epoch 1, got %d records", len(records))
+	}
+	if ap.accounts["erd1first"].Balance.String() != "1" || ap.accounts["erd1lost"] != nil || ap.accounts["erd1second"] == nil {
+		t.Fatalf("expected the accounts without the changes of the expired scroll, got %v", ap.accounts)
+	}
+}
+
+func TestAccountsProcessor_ContinuesFromTheCheckpointBeforeTheStartEpoch(t *testing.T) {
+	ap := newAccountsProcessorWithStakeBalances(t, []uint32{3, 4}, true)
+	stateStorer := newCheckpointStorerStub(t, 2, &accountsProcessorState{
+		LastEpoch:     2,
+		TotalContract: 1,
+		Accounts: map[string]*accountInfo{
+			"erd1first": {Balance: big.NewInt(1), Timestamp: 1},
+		},
 	})
+	ap.stateStorer = stateStorer
+	handler := &pagesElasticStub{
+		pages: []string{
+			`{"hits":{"hits":[{"_source":{"address":"erd1second","balance":"3","timestamp":2}}]}}`,
+			`{"hits":{"hits":[{"_source":{"address":"erd1first","balance":"5","timestamp":3}}]}}`,
+		},
+	}
+	ap.elasticHandler = handler
 
-	ap, _ := NewAccountsProcessor(elsaticC, pubKeyConverter, 1596117600)
+	records, err := ap.ProcessAllAccounts(context.Background(), 3, 5, false)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if stateStorer.loadedEpoch != 2 || handler.scrolls != 2 {
+		t.Fatalf("expected the checkpoint of epoch 2 and 2 processed epochs, got checkpoint %d, %d scrolls", stateStorer.loadedEpoch, handler.scrolls)
+	}
+	if len(records) != 2 || records[0].Epoch != 3 || records[1].Epoch != 4 {
+		t.Fatalf("expected the records of epochs 3 and 4, got %d records", len(records))
+	}
 
-	ap.ProcessAllAccounts(50)
+	for _, record := range records {
+		if record.TotalAddresses != 2 || record.TotalContractAddresses != 1 {
+			t.Fatalf("expected 2 addresses and 1 contract in epoch %d, got %d and %d", record.Epoch, record.TotalAddresses, record.TotalContractAddresses)
+		}
+	}
+	if ap.accounts["erd1first"].Balance.String() != "5" {
+		t.Fatalf("expected the restored account updated by epoch 4, got balance %s", ap.accounts["erd1first"].Balance)
+	}
 }
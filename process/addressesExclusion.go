@@ -0,0 +1,88 @@
+package process
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/ElrondNetwork/statistics-go/config"
+)
+
+type exclusionRule struct {
+	activationEpoch uint32
+	addresses       map[string]struct{}
+	prefixes        []string
+}
+
+// addressesExclusion decides which addresses are not counted in the balance statistics of an epoch
+type addressesExclusion struct {
+	rules []*exclusionRule
+}
+
+// NewAddressesExclusion will create a new instance of addressesExclusion, reading the addresses files of every rule
+func NewAddressesExclusion(exclusionsConfig []config.AddressesExclusionConfig) (*addressesExclusion, error) {
+	rules := make([]*exclusionRule, 0, len(exclusionsConfig))
+	for _, exclusionConfig := range exclusionsConfig {
+		rule := &exclusionRule{
+			activationEpoch: exclusionConfig.ActivationEpoch,
+			addresses:       make(map[string]struct{}),
+			prefixes:        exclusionConfig.AddressPrefixes,
+		}
+
+		for _, address := range exclusionConfig.Addresses {
+			rule.addresses[address] = struct{}{}
+		}
+
+		if exclusionConfig.AddressesFile != "" {
+			addressesFromFile, err := readAddressesFile(exclusionConfig.AddressesFile)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, address := range addressesFromFile {
+				rule.addresses[address] = struct{}{}
+			}
+		}
+
+		rules = append(rules, rule)
+	}
+
+	return &addressesExclusion{
+		rules: rules,
+	}, nil
+}
+
+// IsExcluded returns true if the address is not counted in the balance statistics of the provided epoch
+func (ae *addressesExclusion) IsExcluded(address string, epoch uint32) bool {
+	for _, rule := range ae.rules {
+		if epoch < rule.activationEpoch {
+			continue
+		}
+
+		if _, ok := rule.addresses[address]; ok {
+			return true
+		}
+
+		for _, prefix := range rule.prefixes {
+			if strings.HasPrefix(address, prefix) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func readAddressesFile(pathToFile string) ([]string, error) {
+	byteValue, err := getBytesFromJson(pathToFile)
+	if err != nil {
+		return nil, err
+	}
+
+	addresses := make([]string, 0)
+	err = json.Unmarshal(byteValue, &addresses)
+	if err != nil {
+		return nil, err
+	}
+
+	return addresses, nil
+}
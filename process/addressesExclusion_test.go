@@ -0,0 +1,42 @@
+package process
+
+import (
+	"io/ioutil"
+	"path"
+	"testing"
+
+	"github.com/ElrondNetwork/statistics-go/config"
+)
+
+func TestAddressesExclusion_IsExcluded(t *testing.T) {
+	addressesFile := path.Join(t.TempDir(), "excluded.json")
+	_ = ioutil.WriteFile(addressesFile, []byte(`["erd1fromfile"]`), 0644)
+
+	exclusion, err := NewAddressesExclusion([]config.AddressesExclusionConfig{
+		{
+			ActivationEpoch: 0,
+			Addresses:       []string{"erd1listed"},
+		},
+		{
+			ActivationEpoch: 10,
+			AddressPrefixes: []string{"erd1qqqq"},
+			AddressesFile:   addressesFile,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !exclusion.IsExcluded("erd1listed", 0) {
+		t.Fatal("listed address should be excluded")
+	}
+	if exclusion.IsExcluded("erd1qqqqsystem", 9) || exclusion.IsExcluded("erd1fromfile", 9) {
+		t.Fatal("addresses should not be excluded before the activation epoch")
+	}
+	if !exclusion.IsExcluded("erd1qqqqsystem", 10) || !exclusion.IsExcluded("erd1fromfile", 10) {
+		t.Fatal("addresses should be excluded after the activation epoch")
+	}
+	if exclusion.IsExcluded("erd1other", 10) {
+		t.Fatal("address should not be excluded")
+	}
+}
@@ -0,0 +1,91 @@
+package process
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ElrondNetwork/statistics-go/data"
+)
+
+const egldDecimals = 18
+
+// balanceBuckets classifies balances by the configured thresholds, every bucket counts the balances that are
+// greater or equal than its threshold
+type balanceBuckets struct {
+	thresholds []*big.Int
+	labels     []string
+}
+
+// NewBalanceBuckets will create a new instance of balanceBuckets from thresholds expressed in EGLD (e.g. "0.1", "1000")
+func NewBalanceBuckets(thresholds []string) (*balanceBuckets, error) {
+	if len(thresholds) == 0 {
+		return nil, fmt.Errorf("no balance buckets thresholds provided")
+	}
+
+	bb := &balanceBuckets{
+		thresholds: make([]*big.Int, 0, len(thresholds)),
+		labels:     make([]string, 0, len(thresholds)),
+	}
+	for idx, threshold := range thresholds {
+		value, err := egldToDenominated(threshold)
+		if err != nil {
+			return nil, err
+		}
+		if value.Sign() <= 0 {
+			return nil, fmt.Errorf("balance bucket threshold %s should be positive", threshold)
+		}
+		if idx > 0 && value.Cmp(bb.thresholds[idx-1]) <= 0 {
+			return nil, fmt.Errorf("balance buckets thresholds should be in ascending order, %s is not greater than %s", threshold, thresholds[idx-1])
+		}
+
+		bb.thresholds = append(bb.thresholds, value)
+		bb.labels = append(bb.labels, strings.TrimSpace(threshold))
+	}
+
+	return bb, nil
+}
+
+// NewDistribution returns the buckets of a distribution with all the counts set to 0
+func (bb *balanceBuckets) NewDistribution() []*data.BalanceBucket {
+	distribution := make([]*data.BalanceBucket, len(bb.labels))
+	for idx, label := range bb.labels {
+		distribution[idx] = &data.BalanceBucket{
+			Threshold: label,
+		}
+	}
+
+	return distribution
+}
+
+// AddBalance will increment the count of every bucket with a threshold lower or equal than the balance
+func (bb *balanceBuckets) AddBalance(distribution []*data.BalanceBucket, balance *big.Int) {
+	for idx, threshold := range bb.thresholds {
+		if balance.Cmp(threshold) < 0 {
+			return
+		}
+
+		distribution[idx].Count++
+	}
+}
+
+// egldToDenominated converts a decimal EGLD amount in its denominated value
+func egldToDenominated(amount string) (*big.Int, error) {
+	parts := strings.Split(strings.TrimSpace(amount), ".")
+	if len(parts) > 2 || parts[0] == "" || (len(parts) == 2 && len(parts[1]) > egldDecimals) {
+		return nil, fmt.Errorf("invalid EGLD amount %s", amount)
+	}
+
+	decimals := ""
+	if len(parts) == 2 {
+		decimals = parts[1]
+	}
+	decimals += strings.Repeat("0", egldDecimals-len(decimals))
+
+	value, ok := big.NewInt(0).SetString(parts[0]+decimals, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid EGLD amount %s", amount)
+	}
+
+	return value, nil
+}
@@ -0,0 +1,45 @@
+package process
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestBalanceBuckets_AddBalance(t *testing.T) {
+	buckets, err := NewBalanceBuckets([]string{"0.01", "1", "100000"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	distribution := buckets.NewDistribution()
+	buckets.AddBalance(distribution, stringToBigInt("10000000000000000"))
+	buckets.AddBalance(distribution, stringToBigInt("999999999999999999"))
+	buckets.AddBalance(distribution, stringToBigInt("1000000000000000000"))
+	buckets.AddBalance(distribution, big.NewInt(0).Mul(big.NewInt(200000), stringToBigInt("1000000000000000000")))
+	buckets.AddBalance(distribution, big.NewInt(1))
+
+	expected := []int{4, 2, 1}
+	for idx, bucket := range distribution {
+		if bucket.Count != expected[idx] {
+			t.Fatalf("bucket %s: expected %d, got %d", bucket.Threshold, expected[idx], bucket.Count)
+		}
+	}
+}
+
+func TestNewBalanceBuckets_InvalidThresholds(t *testing.T) {
+	invalidThresholds := [][]string{
+		{},
+		{"1", "0.1"},
+		{"0"},
+		{"1.2.3"},
+		{"0.0000000000000000001"},
+		{"abc"},
+	}
+
+	for _, thresholds := range invalidThresholds {
+		_, err := NewBalanceBuckets(thresholds)
+		if err == nil {
+			t.Fatalf("expected error for thresholds %v", thresholds)
+		}
+	}
+}
@@ -6,123 +6,61 @@ import (
 	"io/ioutil"
 	"math/big"
 	"os"
-	"path"
 
 	"github.com/ElrondNetwork/statistics-go/data"
 )
 
-func DumpBalances(
+// MergeStakeBalances returns the total staked balance of every user, both in the legacy delegation and in staking
+func MergeStakeBalances(
 	delegationLegacyUsers map[string]*big.Int,
 	stakingUsers map[string]*big.Int,
-	pathToFolder string,
-	epoch uint32,
-) {
-	checkBalances2(delegationLegacyUsers, "delegation legacy")
-	checkBalances2(stakingUsers, "staking")
+	buckets BalanceBucketsHandler,
+) map[string]string {
+	printBalancesDistribution("delegation legacy", delegationLegacyUsers, buckets)
+	printBalancesDistribution("staking", stakingUsers, buckets)
 
-	mapUniqueUsers := map[string]string{}
+	mapUniqueUsers := map[string]*big.Int{}
 	for key, value := range stakingUsers {
-		mapUniqueUsers[key] = value.String()
+		mapUniqueUsers[key] = big.NewInt(0).Set(value)
 	}
 
 	for key, value := range delegationLegacyUsers {
 		_, ok := mapUniqueUsers[key]
 		if !ok {
-			mapUniqueUsers[key] = value.String()
+			mapUniqueUsers[key] = big.NewInt(0).Set(value)
 			continue
 		}
 
-		mapUniqueUsers[key] = big.NewInt(0).Add(stringToBigInt(mapUniqueUsers[key]), value).String()
+		mapUniqueUsers[key].Add(mapUniqueUsers[key], value)
 	}
 
-	checkBalances(mapUniqueUsers)
+	printBalancesDistribution("unique users", mapUniqueUsers, buckets)
 
-	bytes, _ := json.MarshalIndent(mapUniqueUsers, "", " ")
-	_ = ioutil.WriteFile(path.Join(pathToFolder, fmt.Sprintf("epoch%d.json", epoch)), bytes, 0644)
-}
-
-func checkBalances2(accts map[string]*big.Int, message string) {
-	fmt.Println(message)
-	currentEpochStats := &data.StatisticsAddressesBalanceEpoch{}
-	for _, b := range accts {
-		currentBalance := big.NewInt(0).SetBytes(b.Bytes())
-		if currentBalance.Cmp(nonZero) > 0 {
-			currentEpochStats.NonZero++
-		}
-
-		if currentBalance.Cmp(b01EGLD) >= 0 {
-			currentEpochStats.B01EGLD++
-		}
-
-		if currentBalance.Cmp(b1EGLD) >= 0 {
-			currentEpochStats.B1EGLD++
-		}
-
-		if currentBalance.Cmp(b10EGLD) >= 0 {
-			currentEpochStats.B10EGLD++
-		}
-
-		if currentBalance.Cmp(b100EGLD) >= 0 {
-			currentEpochStats.B100EGLD++
-		}
-
-		if currentBalance.Cmp(b1KEGLD) >= 0 {
-			currentEpochStats.B1kEGLD++
-		}
+	balances := make(map[string]string, len(mapUniqueUsers))
+	for key, value := range mapUniqueUsers {
+		balances[key] = value.String()
 	}
 
-	bytes, _ := json.MarshalIndent(currentEpochStats, "", " ")
-	fmt.Println(string(bytes))
+	return balances
 }
 
-func checkBalances(accts map[string]string) {
-	currentEpochStats := &data.StatisticsAddressesBalanceEpoch{}
-	for _, b := range accts {
-		currentBalance := big.NewInt(0).SetBytes(stringToBigInt(b).Bytes())
-		if currentBalance.Cmp(nonZero) > 0 {
+func printBalancesDistribution(message string, accts map[string]*big.Int, buckets BalanceBucketsHandler) {
+	fmt.Println(message)
+	currentEpochStats := &data.StatisticsAddressesBalanceEpoch{
+		BalanceDistribution: buckets.NewDistribution(),
+	}
+	for _, balance := range accts {
+		if balance.Cmp(zero) > 0 {
 			currentEpochStats.NonZero++
 		}
 
-		if currentBalance.Cmp(b01EGLD) >= 0 {
-			currentEpochStats.B01EGLD++
-		}
-
-		if currentBalance.Cmp(b1EGLD) >= 0 {
-			currentEpochStats.B1EGLD++
-		}
-
-		if currentBalance.Cmp(b10EGLD) >= 0 {
-			currentEpochStats.B10EGLD++
-		}
-
-		if currentBalance.Cmp(b100EGLD) >= 0 {
-			currentEpochStats.B100EGLD++
-		}
-
-		if currentBalance.Cmp(b1KEGLD) >= 0 {
-			currentEpochStats.B1kEGLD++
-		}
+		buckets.AddBalance(currentEpochStats.BalanceDistribution, balance)
 	}
 
 	bytes, _ := json.MarshalIndent(currentEpochStats, "", " ")
 	fmt.Println(string(bytes))
 }
 
-func ReadBalances(pathToBalances string, epoch uint32) (map[string]string, error) {
-	byteValue, err := getBytesFromJson(path.Join(pathToBalances, fmt.Sprintf("epoch%d.json", epoch)))
-	if err != nil {
-		return nil, err
-	}
-
-	balances := map[string]string{}
-	err = json.Unmarshal(byteValue, &balances)
-	if err != nil {
-		return nil, err
-	}
-
-	return balances, nil
-}
-
 func getBytesFromJson(pathToFile string) ([]byte, error) {
 	jsonFile, err := os.Open(pathToFile)
 	// if we os.Open returns an error then handle it
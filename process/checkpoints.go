@@ -0,0 +1,38 @@
+package process
+
+import (
+	"log"
+	"math"
+)
+
+// checkpointToLoad returns the epoch of the checkpoint that holds the state needed before processing the start epoch,
+// which is the closest checkpoint before it. When resuming, the most recent checkpoint is used no matter the start epoch
+func checkpointToLoad(stateStorer StateStorer, name string, startEpoch uint32, resume bool) (uint32, bool, error) {
+	maxEpoch := uint32(math.MaxUint32)
+	if !resume {
+		if startEpoch == 0 {
+			return 0, false, nil
+		}
+		maxEpoch = startEpoch - 1
+	}
+
+	checkpointEpoch, found, err := stateStorer.LastCheckpointEpoch(name, maxEpoch)
+	if err != nil || resume {
+		return checkpointEpoch, found, err
+	}
+
+	if !found {
+		log.Printf("no %s checkpoint before epoch %d, all the epochs from 0 are processed again \n", name, startEpoch)
+	} else if checkpointEpoch < maxEpoch {
+		log.Printf("the closest %s checkpoint before epoch %d is the one of epoch %d, the epochs from %d are processed again \n",
+			name, startEpoch, checkpointEpoch, checkpointEpoch+1)
+	}
+
+	return checkpointEpoch, found, nil
+}
+
+// isCheckpointEpoch returns true if the full state is saved after the provided epoch, which happens every interval of
+// epochs and after the last epoch of the run. An interval of 0 saves the state after every epoch
+func isCheckpointEpoch(epoch uint32, endEpoch uint32, interval uint32) bool {
+	return interval == 0 || epoch%interval == 0 || epoch+1 == endEpoch
+}
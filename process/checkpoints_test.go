@@ -0,0 +1,63 @@
+package process
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// checkpointStorerStub holds the checkpoint of a single epoch and records the epochs of the saved checkpoints
+type checkpointStorerStub struct {
+	epoch       uint32
+	state       []byte
+	loadedEpoch uint32
+	savedEpochs []uint32
+}
+
+func newCheckpointStorerStub(t *testing.T, epoch uint32, state interface{}) *checkpointStorerStub {
+	stateBytes, err := json.Marshal(state)
+	if err != nil {
+		t.Fatalf("cannot marshal the checkpoint, error %v", err)
+	}
+
+	return &checkpointStorerStub{epoch: epoch, state: stateBytes}
+}
+
+func (css *checkpointStorerStub) SaveCheckpoint(_ string, epoch uint32, _ interface{}) error {
+	css.savedEpochs = append(css.savedEpochs, epoch)
+	return nil
+}
+
+func (css *checkpointStorerStub) LoadCheckpoint(_ string, epoch uint32, state interface{}) error {
+	css.loadedEpoch = epoch
+	return json.Unmarshal(css.state, state)
+}
+
+func (css *checkpointStorerStub) LastCheckpointEpoch(_ string, maxEpoch uint32) (uint32, bool, error) {
+	if css.epoch > maxEpoch {
+		return 0, false, nil
+	}
+
+	return css.epoch, true, nil
+}
+
+func TestIsCheckpointEpoch(t *testing.T) {
+	tests := []struct {
+		epoch    uint32
+		endEpoch uint32
+		interval uint32
+		expected bool
+	}{
+		{epoch: 3, endEpoch: 10, interval: 0, expected: true},
+		{epoch: 0, endEpoch: 10, interval: 5, expected: true},
+		{epoch: 5, endEpoch: 10, interval: 5, expected: true},
+		{epoch: 6, endEpoch: 10, interval: 5, expected: false},
+		{epoch: 9, endEpoch: 10, interval: 5, expected: true},
+	}
+
+	for _, test := range tests {
+		result := isCheckpointEpoch(test.epoch, test.endEpoch, test.interval)
+		if result != test.expected {
+			t.Fatalf("expected %v for epoch %d of %d with interval %d, got %v", test.expected, test.epoch, test.endEpoch, test.interval, result)
+		}
+	}
+}
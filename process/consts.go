@@ -1,6 +0,0 @@
-package process
-
-const (
-	accountsHistoryIndex = "accountshistory"
-	transactionsIndex    = "transactions"
-)
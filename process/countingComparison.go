@@ -0,0 +1,79 @@
+package process
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"time"
+)
+
+// countingComparison holds the metrics of an epoch counted by scanning the transactions and by aggregations
+type countingComparison struct {
+	Epoch                             uint32              `json:"epoch"`
+	ScanDurationInMilliseconds        int64               `json:"scanDurationInMilliseconds"`
+	AggregationDurationInMilliseconds int64               `json:"aggregationDurationInMilliseconds"`
+	Metrics                           []*metricComparison `json:"metrics"`
+}
+
+// metricComparison holds the values of a metric in both counting modes, the difference is aggregation minus scan
+type metricComparison struct {
+	Name        string `json:"name"`
+	Scan        int    `json:"scan"`
+	Aggregation int    `json:"aggregation"`
+	Difference  int    `json:"difference"`
+}
+
+// compareCountings compares the scan and the aggregation results of the epoch. It has to be called before the
+// addresses of the epoch are merged, so the new addresses of both results are counted against the same addresses
+func (tp *transactionsProc) compareCountings(tc *transactionsCounter) *countingComparison {
+	scanNew, scanNewContracts := tp.countNewAddresses(tc.seenAddresses)
+	aggregationNew, aggregationNewContracts := tp.countNewAddresses(tc.aggregated.seenAddresses)
+
+	comparison := &countingComparison{
+		Epoch:                             tc.epoch,
+		ScanDurationInMilliseconds:        durationInMilliseconds(tc.scanDuration),
+		AggregationDurationInMilliseconds: durationInMilliseconds(tc.aggregationDuration),
+	}
+	comparison.add("dailyTransactions", tc.stats.DailyTransactions, tc.aggregated.stats.DailyTransactions)
+	comparison.add("dailyContractCalls", tc.stats.DailyContractCalls, tc.aggregated.stats.DailyContractCalls)
+	comparison.add("dailyActiveAccounts", len(tc.dailyActiveAccounts), len(tc.aggregated.dailyActiveAccounts))
+	comparison.add("dailyActiveContractAccounts", len(tc.dailyActiveContracts), len(tc.aggregated.dailyActiveContracts))
+	comparison.add("dailyNewAddresses", scanNew, aggregationNew)
+	comparison.add("dailyNewContractAddresses", scanNewContracts, aggregationNewContracts)
+
+	return comparison
+}
+
+func (cc *countingComparison) add(name string, scan int, aggregation int) {
+	cc.Metrics = append(cc.Metrics, &metricComparison{
+		Name:        name,
+		Scan:        scan,
+		Aggregation: aggregation,
+		Difference:  aggregation - scan,
+	})
+}
+
+func (cc *countingComparison) log() {
+	log.Printf("compare transactions counting epoch %d: scan %d ms, aggregation %d ms",
+		cc.Epoch, cc.ScanDurationInMilliseconds, cc.AggregationDurationInMilliseconds)
+	for _, metric := range cc.Metrics {
+		if metric.Difference != 0 {
+			log.Printf("    %s: scan %d, aggregation %d", metric.Name, metric.Scan, metric.Aggregation)
+		}
+	}
+}
+
+// writeComparisonReport writes the comparisons of all the epochs processed in the run, after every epoch, so the
+// report is complete also when the run is interrupted
+func writeComparisonReport(pathToFile string, comparisons []*countingComparison) error {
+	bytes, err := json.MarshalIndent(comparisons, "", "    ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(pathToFile, bytes, 0644)
+}
+
+func durationInMilliseconds(duration time.Duration) int64 {
+	return int64(duration / time.Millisecond)
+}
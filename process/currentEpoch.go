@@ -0,0 +1,91 @@
+package process
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	dataIndexer "github.com/ElrondNetwork/elastic-indexer-go/data"
+	"github.com/ElrondNetwork/elrond-go/core"
+	"github.com/ElrondNetwork/statistics-go/data"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	// CurrentEpochGateway reads the current epoch from the metachain status of the gateway API
+	CurrentEpochGateway = "gateway"
+	// CurrentEpochElastic reads the current epoch from the last metablock of the blocks index
+	CurrentEpochElastic = "elastic"
+)
+
+type gatewayCurrentEpoch struct {
+	restClient RestClientHandler
+}
+
+// NewGatewayCurrentEpoch will create a new instance of gatewayCurrentEpoch
+func NewGatewayCurrentEpoch(restClient RestClientHandler) (*gatewayCurrentEpoch, error) {
+	if restClient == nil {
+		return nil, fmt.Errorf("nil rest client")
+	}
+
+	return &gatewayCurrentEpoch{
+		restClient: restClient,
+	}, nil
+}
+
+// CurrentEpoch returns the epoch of the metachain
+func (gce *gatewayCurrentEpoch) CurrentEpoch(ctx context.Context) (uint32, error) {
+	status, err := getGatewayData(ctx, gce.restClient, fmt.Sprintf("/network/status/%d", core.MetachainShardId))
+	if err != nil {
+		return 0, err
+	}
+
+	epoch := gjson.Get(status, "status.erd_epoch_number")
+	if !epoch.Exists() {
+		return 0, fmt.Errorf("cannot read the current epoch from the network status")
+	}
+
+	return uint32(epoch.Uint()), nil
+}
+
+type elasticCurrentEpoch struct {
+	elasticHandler ElasticHandler
+	blocksIndex    string
+}
+
+// NewElasticCurrentEpoch will create a new instance of elasticCurrentEpoch that reads the provided blocks index
+func NewElasticCurrentEpoch(elasticHandler ElasticHandler, blocksIndex string) (*elasticCurrentEpoch, error) {
+	if elasticHandler == nil {
+		return nil, fmt.Errorf("nil elastic handler")
+	}
+
+	return &elasticCurrentEpoch{
+		elasticHandler: elasticHandler,
+		blocksIndex:    blocksIndex,
+	}, nil
+}
+
+// CurrentEpoch returns the epoch of the last indexed metablock
+func (ece *elasticCurrentEpoch) CurrentEpoch(ctx context.Context) (uint32, error) {
+	response, err := ece.elasticHandler.DoSearchRequest(ctx, lastMetaBlockQuery(), ece.blocksIndex)
+	if err != nil {
+		return 0, err
+	}
+
+	searchResponse := &data.SearchResponse{}
+	err = json.Unmarshal(response, searchResponse)
+	if err != nil {
+		return 0, err
+	}
+	if len(searchResponse.Hits.Hits) == 0 {
+		return 0, fmt.Errorf("cannot find any indexed metablock")
+	}
+
+	block := &dataIndexer.Block{}
+	err = json.Unmarshal(searchResponse.Hits.Hits[0].OBJ, block)
+	if err != nil {
+		return 0, err
+	}
+
+	return block.Epoch, nil
+}
@@ -0,0 +1,64 @@
+package process
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+type elasticSearchStub struct {
+	response []byte
+	index    string
+}
+
+func (ess *elasticSearchStub) DoSearchRequest(_ context.Context, _ *bytes.Buffer, index string) ([]byte, error) {
+	ess.index = index
+	return ess.response, nil
+}
+
+func (ess *elasticSearchStub) DoAggregationRequest(_ context.Context, _ *bytes.Buffer, _ string) ([]byte, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (ess *elasticSearchStub) DoScrollRequestAllDocuments(_ context.Context, _ *bytes.Buffer, _ string, _ func(responseBytes []byte) error) error {
+	return fmt.Errorf("not implemented")
+}
+
+func (ess *elasticSearchStub) DoSlicedScrollRequestAllDocuments(_ context.Context, _ *bytes.Buffer, _ string, _ int, _ func(responseBytes []byte) error) error {
+	return fmt.Errorf("not implemented")
+}
+
+func TestGatewayCurrentEpoch(t *testing.T) {
+	gce, _ := NewGatewayCurrentEpoch(&restClientStub{})
+
+	epoch, err := gce.CurrentEpoch(context.Background())
+	if err != nil || epoch != 4 {
+		t.Fatalf("expected epoch 4, got %d, error %v", epoch, err)
+	}
+}
+
+func TestElasticCurrentEpoch(t *testing.T) {
+	handler := &elasticSearchStub{}
+	ece, _ := NewElasticCurrentEpoch(handler, "mainnet-blocks")
+
+	_, err := ece.CurrentEpoch(context.Background())
+	if err == nil {
+		t.Fatal("expected error for an empty response")
+	}
+
+	response, _ := json.Marshal(map[string]interface{}{
+		"hits": map[string]interface{}{
+			"hits": []interface{}{
+				map[string]interface{}{"_source": map[string]interface{}{"epoch": 7, "timestamp": 1000}},
+			},
+		},
+	})
+	handler.response = response
+
+	epoch, err := ece.CurrentEpoch(context.Background())
+	if err != nil || epoch != 7 || handler.index != "mainnet-blocks" {
+		t.Fatalf("expected epoch 7 from mainnet-blocks, got %d from %s, error %v", epoch, handler.index, err)
+	}
+}
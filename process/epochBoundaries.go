@@ -0,0 +1,193 @@
+package process
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	dataIndexer "github.com/ElrondNetwork/elastic-indexer-go/data"
+	"github.com/ElrondNetwork/elrond-go/core"
+	"github.com/ElrondNetwork/statistics-go/data"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	// EpochBoundariesFixed approximates every epoch with a 24 hours window starting from the genesis time
+	EpochBoundariesFixed = "fixed"
+	// EpochBoundariesElastic reads the epoch start metablocks from the blocks index
+	EpochBoundariesElastic = "elastic"
+	// EpochBoundariesGateway reads the epoch start metablocks from the gateway API
+	EpochBoundariesGateway = "gateway"
+)
+
+// fixedEpochBoundaries computes the boundaries of an epoch as a fixed 24 hours window
+type fixedEpochBoundaries struct {
+	genesisTime int
+}
+
+// NewFixedEpochBoundaries will create a new instance of fixedEpochBoundaries
+func NewFixedEpochBoundaries(genesisTime int) (*fixedEpochBoundaries, error) {
+	return &fixedEpochBoundaries{
+		genesisTime: genesisTime,
+	}, nil
+}
+
+// EpochBoundaries returns the start and the end timestamps of the provided epoch
+func (feb *fixedEpochBoundaries) EpochBoundaries(_ context.Context, epoch uint32) (int, int, error) {
+	return feb.genesisTime + int(epoch)*secondsInADay, feb.genesisTime + int(epoch+1)*secondsInADay, nil
+}
+
+// epochStartTimeFetcher is the source of the timestamp of the first metablock of an epoch
+type epochStartTimeFetcher func(ctx context.Context, epoch uint32) (int, error)
+
+// chainEpochBoundaries computes the boundaries of an epoch from the epoch start metablocks of the chain
+type chainEpochBoundaries struct {
+	genesisTime      int
+	fetchStartTime   epochStartTimeFetcher
+	mutStartTimes    sync.RWMutex
+	epochsStartTimes map[uint32]int
+}
+
+// NewElasticEpochBoundaries will create a new instance of epoch boundaries provider that reads the epoch start
+// metablocks from the provided blocks index
+func NewElasticEpochBoundaries(elasticHandler ElasticHandler, blocksIndex string, genesisTime int) (*chainEpochBoundaries, error) {
+	fetcher := func(ctx context.Context, epoch uint32) (int, error) {
+		response, err := elasticHandler.DoSearchRequest(ctx, epochStartMetaBlockQuery(epoch), blocksIndex)
+		if err != nil {
+			return 0, err
+		}
+
+		searchResponse := &data.SearchResponse{}
+		err = json.Unmarshal(response, searchResponse)
+		if err != nil {
+			return 0, err
+		}
+		if len(searchResponse.Hits.Hits) == 0 {
+			return 0, fmt.Errorf("cannot find the start metablock of epoch %d", epoch)
+		}
+
+		block := &dataIndexer.Block{}
+		err = json.Unmarshal(searchResponse.Hits.Hits[0].OBJ, block)
+		if err != nil {
+			return 0, err
+		}
+
+		return int(block.Timestamp), nil
+	}
+
+	return newChainEpochBoundaries(genesisTime, fetcher), nil
+}
+
+// NewGatewayEpochBoundaries will create a new instance of epoch boundaries provider that finds the epoch start
+// metablocks through the gateway API
+func NewGatewayEpochBoundaries(restClient RestClientHandler, genesisTime int) (*chainEpochBoundaries, error) {
+	fetcher := func(ctx context.Context, epoch uint32) (int, error) {
+		return fetchEpochStartTimeFromGateway(ctx, restClient, epoch)
+	}
+
+	return newChainEpochBoundaries(genesisTime, fetcher), nil
+}
+
+func newChainEpochBoundaries(genesisTime int, fetcher epochStartTimeFetcher) *chainEpochBoundaries {
+	return &chainEpochBoundaries{
+		genesisTime:      genesisTime,
+		fetchStartTime:   fetcher,
+		epochsStartTimes: map[uint32]int{},
+	}
+}
+
+// EpochBoundaries returns the start and the end timestamps of the provided epoch. The end of an epoch is the
+// second before the start of the next one, so it cannot be computed before the epoch is closed
+func (ceb *chainEpochBoundaries) EpochBoundaries(ctx context.Context, epoch uint32) (int, int, error) {
+	start, err := ceb.epochStartTime(ctx, epoch)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	nextStart, err := ceb.epochStartTime(ctx, epoch+1)
+	if err != nil {
+		return 0, 0, fmt.Errorf("epoch %d is not closed: %w", epoch, err)
+	}
+
+	return start, nextStart - 1, nil
+}
+
+func (ceb *chainEpochBoundaries) epochStartTime(ctx context.Context, epoch uint32) (int, error) {
+	if epoch == 0 {
+		return ceb.genesisTime, nil
+	}
+
+	ceb.mutStartTimes.RLock()
+	startTime, ok := ceb.epochsStartTimes[epoch]
+	ceb.mutStartTimes.RUnlock()
+	if ok {
+		return startTime, nil
+	}
+
+	startTime, err := ceb.fetchStartTime(ctx, epoch)
+	if err != nil {
+		return 0, err
+	}
+
+	ceb.mutStartTimes.Lock()
+	ceb.epochsStartTimes[epoch] = startTime
+	ceb.mutStartTimes.Unlock()
+
+	return startTime, nil
+}
+
+// fetchEpochStartTimeFromGateway does a binary search over the metachain nonces for the first metablock of the epoch
+func fetchEpochStartTimeFromGateway(ctx context.Context, restClient RestClientHandler, epoch uint32) (int, error) {
+	status, err := getGatewayData(ctx, restClient, fmt.Sprintf("/network/status/%d", core.MetachainShardId))
+	if err != nil {
+		return 0, err
+	}
+
+	highestNonce := gjson.Get(status, "status.erd_nonce").Uint()
+	currentEpoch := uint32(gjson.Get(status, "status.erd_epoch_number").Uint())
+	if epoch > currentEpoch {
+		return 0, fmt.Errorf("epoch %d did not start yet, current epoch is %d", epoch, currentEpoch)
+	}
+
+	low, high := uint64(0), highestNonce
+	timestamp := 0
+	for low < high {
+		middle := low + (high-low)/2
+		block, errGet := getGatewayData(ctx, restClient, fmt.Sprintf("/block/%d/by-nonce/%d", core.MetachainShardId, middle))
+		if errGet != nil {
+			return 0, errGet
+		}
+
+		if uint32(gjson.Get(block, "block.epoch").Uint()) < epoch {
+			low = middle + 1
+			continue
+		}
+
+		high = middle
+		timestamp = int(gjson.Get(block, "block.timestamp").Int())
+	}
+
+	if timestamp == 0 {
+		block, errGet := getGatewayData(ctx, restClient, fmt.Sprintf("/block/%d/by-nonce/%d", core.MetachainShardId, low))
+		if errGet != nil {
+			return 0, errGet
+		}
+		timestamp = int(gjson.Get(block, "block.timestamp").Int())
+	}
+
+	return timestamp, nil
+}
+
+func getGatewayData(ctx context.Context, restClient RestClientHandler, path string) (string, error) {
+	genericAPIResponse := &data.GenericAPIResponse{}
+	err := restClient.CallGetRestEndPoint(ctx, path, genericAPIResponse)
+	if err != nil {
+		return "", err
+	}
+	if genericAPIResponse.Error != "" {
+		return "", fmt.Errorf("%s", genericAPIResponse.Error)
+	}
+
+	return string(genericAPIResponse.Data), nil
+}
@@ -0,0 +1,64 @@
+package process
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ElrondNetwork/statistics-go/data"
+)
+
+const testNoncesPerEpoch = 25
+
+type restClientStub struct{}
+
+func (rcs *restClientStub) CallGetRestEndPoint(_ context.Context, path string, value interface{}) error {
+	response := value.(*data.GenericAPIResponse)
+	if strings.HasPrefix(path, "/network/status") {
+		response.Data = json.RawMessage(`{"status":{"erd_nonce":100,"erd_epoch_number":4}}`)
+		return nil
+	}
+
+	var nonce uint64
+	_, err := fmt.Sscanf(path[strings.LastIndex(path, "/")+1:], "%d", &nonce)
+	if err != nil {
+		return err
+	}
+
+	response.Data = json.RawMessage(fmt.Sprintf(`{"block":{"nonce":%d,"epoch":%d,"timestamp":%d}}`, nonce, nonce/testNoncesPerEpoch, 1000+nonce*6))
+	return nil
+}
+
+func (rcs *restClientStub) CallPostRestEndPoint(_ context.Context, _ string, _ interface{}, _ interface{}) error {
+	return nil
+}
+
+func TestFixedEpochBoundaries(t *testing.T) {
+	feb, _ := NewFixedEpochBoundaries(1000)
+
+	start, stop, _ := feb.EpochBoundaries(context.Background(), 2)
+	if start != 1000+2*secondsInADay || stop != 1000+3*secondsInADay {
+		t.Fatalf("unexpected boundaries %d-%d", start, stop)
+	}
+}
+
+func TestGatewayEpochBoundaries(t *testing.T) {
+	geb, _ := NewGatewayEpochBoundaries(&restClientStub{}, 1000)
+
+	start, stop, err := geb.EpochBoundaries(context.Background(), 0)
+	if err != nil || start != 1000 || stop != 1000+testNoncesPerEpoch*6-1 {
+		t.Fatalf("unexpected boundaries %d-%d, error %v", start, stop, err)
+	}
+
+	start, stop, err = geb.EpochBoundaries(context.Background(), 2)
+	if err != nil || start != 1000+2*testNoncesPerEpoch*6 || stop != 1000+3*testNoncesPerEpoch*6-1 {
+		t.Fatalf("unexpected boundaries %d-%d, error %v", start, stop, err)
+	}
+
+	_, _, err = geb.EpochBoundaries(context.Background(), 4)
+	if err == nil {
+		t.Fatal("expected error for an epoch that is not closed")
+	}
+}
@@ -0,0 +1,23 @@
+package process
+
+import "time"
+
+const (
+	// StatisticTransactions is the name of the transactions statistics in the epoch metrics
+	StatisticTransactions = "transactions"
+	// StatisticAccounts is the name of the accounts statistics in the epoch metrics
+	StatisticAccounts = "accounts"
+	// StatisticStake is the name of the stake statistics in the epoch metrics
+	StatisticStake = "stake"
+)
+
+type disabledEpochMetrics struct{}
+
+// NewDisabledEpochMetrics will create a new instance of disabledEpochMetrics, used when the metrics are not enabled
+func NewDisabledEpochMetrics() *disabledEpochMetrics {
+	return &disabledEpochMetrics{}
+}
+
+// EpochProcessed does nothing
+func (dem *disabledEpochMetrics) EpochProcessed(_ string, _ time.Duration, _ bool) {
+}
@@ -0,0 +1,66 @@
+package process
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ElrondNetwork/statistics-go/config"
+)
+
+// the indices created by the elastic indexer, read when no other index is configured
+const (
+	defaultAccountsHistoryIndex = "accountshistory"
+	defaultTransactionsIndex    = "transactions"
+	defaultBlocksIndex          = "blocks"
+)
+
+// Indices holds the elasticsearch indices read for every dataset. Every index can be an index name, an alias, a
+// pattern or a comma separated list of them
+type Indices struct {
+	Transactions    string
+	AccountsHistory string
+	Blocks          string
+}
+
+// NewIndices will create the indices of every dataset from the configuration. The empty indices are the ones of the
+// elastic indexer and the prefix is added to every name, alias and pattern
+func NewIndices(indicesConfig config.IndicesConfig) (Indices, error) {
+	indices := Indices{
+		Transactions:    withPrefix(indicesConfig.Prefix, valueOrDefault(indicesConfig.Transactions, defaultTransactionsIndex)),
+		AccountsHistory: withPrefix(indicesConfig.Prefix, valueOrDefault(indicesConfig.AccountsHistory, defaultAccountsHistoryIndex)),
+		Blocks:          withPrefix(indicesConfig.Prefix, valueOrDefault(indicesConfig.Blocks, defaultBlocksIndex)),
+	}
+
+	for _, index := range []string{indices.Transactions, indices.AccountsHistory, indices.Blocks} {
+		for _, name := range strings.Split(index, ",") {
+			if strings.TrimPrefix(name, "-") == indicesConfig.Prefix {
+				return Indices{}, fmt.Errorf("invalid index %s, empty name in the list", index)
+			}
+		}
+	}
+
+	return indices, nil
+}
+
+// withPrefix adds the prefix to every name of a comma separated list, after the minus sign of the excluded ones
+func withPrefix(prefix string, index string) string {
+	names := strings.Split(index, ",")
+	for idx, name := range names {
+		name = strings.TrimSpace(name)
+		if strings.HasPrefix(name, "-") {
+			names[idx] = "-" + prefix + strings.TrimPrefix(name, "-")
+			continue
+		}
+		names[idx] = prefix + name
+	}
+
+	return strings.Join(names, ",")
+}
+
+func valueOrDefault(value string, defaultValue string) string {
+	if strings.TrimSpace(value) == "" {
+		return defaultValue
+	}
+
+	return value
+}
@@ -0,0 +1,46 @@
+package process
+
+import (
+	"testing"
+
+	"github.com/ElrondNetwork/statistics-go/config"
+)
+
+// testIndices are the indices of the elastic indexer
+var testIndices = Indices{
+	Transactions:    "transactions",
+	AccountsHistory: "accountshistory",
+	Blocks:          "blocks",
+}
+
+func TestNewIndices(t *testing.T) {
+	indices, err := NewIndices(config.IndicesConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if indices != testIndices {
+		t.Fatalf("expected the indices of the elastic indexer, got %+v", indices)
+	}
+
+	indices, err = NewIndices(config.IndicesConfig{
+		Prefix:          "mainnet-",
+		Transactions:    "transactions-*, -transactions-old",
+		AccountsHistory: "accountshistory-alias",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if indices.Transactions != "mainnet-transactions-*,-mainnet-transactions-old" {
+		t.Fatalf("unexpected transactions index %s", indices.Transactions)
+	}
+	if indices.AccountsHistory != "mainnet-accountshistory-alias" || indices.Blocks != "mainnet-blocks" {
+		t.Fatalf("unexpected indices %+v", indices)
+	}
+}
+
+func TestNewIndices_EmptyName(t *testing.T) {
+	_, err := NewIndices(config.IndicesConfig{Prefix: "mainnet-", Transactions: "transactions,"})
+	if err == nil {
+		t.Fatal("expected error for an empty name in the list")
+	}
+}
@@ -2,27 +2,83 @@ package process
 
 import (
 	"bytes"
+	"context"
+	"math/big"
+	"time"
+
+	"github.com/ElrondNetwork/statistics-go/data"
 )
 
 type ElasticHandler interface {
-	DoSearchRequest(query *bytes.Buffer, index string) ([]byte, error)
-	DoScrollRequestAllDocuments(query *bytes.Buffer, index string, handlerFunc func(responseBytes []byte) error) error
+	DoSearchRequest(ctx context.Context, query *bytes.Buffer, index string) ([]byte, error)
+	DoAggregationRequest(ctx context.Context, query *bytes.Buffer, index string) ([]byte, error)
+	DoScrollRequestAllDocuments(ctx context.Context, query *bytes.Buffer, index string, handlerFunc func(responseBytes []byte) error) error
+	DoSlicedScrollRequestAllDocuments(ctx context.Context, query *bytes.Buffer, index string, numSlices int, handlerFunc func(responseBytes []byte) error) error
 }
 
 // // RestClientHandler defines what a rest client should be able do
 type RestClientHandler interface {
-	CallGetRestEndPoint(path string, value interface{}) error
-	CallPostRestEndPoint(path string, data interface{}, response interface{}) error
+	CallGetRestEndPoint(ctx context.Context, path string, value interface{}) error
+	CallPostRestEndPoint(ctx context.Context, path string, data interface{}, response interface{}) error
+}
+
+// EpochBoundariesHandler defines what a provider of the epochs start and end timestamps should be able to do
+type EpochBoundariesHandler interface {
+	EpochBoundaries(ctx context.Context, epoch uint32) (int, int, error)
+}
+
+// CurrentEpochHandler defines what a source of the current epoch of the chain should be able to do
+type CurrentEpochHandler interface {
+	CurrentEpoch(ctx context.Context) (uint32, error)
+}
+
+// EpochMetricsHandler defines what a recorder of the processing duration and of the failures of every epoch should
+// be able to do
+type EpochMetricsHandler interface {
+	EpochProcessed(statistic string, duration time.Duration, failed bool)
+}
+
+// StakeBalancesHandler defines what a holder of the staked balances of every epoch should be able to do
+type StakeBalancesHandler interface {
+	SetStakeBalances(epoch uint32, balances map[string]string) error
+	GetStakeBalances(epoch uint32) (map[string]string, error)
+	HasStakeBalances(epoch uint32) (bool, error)
+	ReleaseStakeBalances(epoch uint32)
+}
+
+// AddressesExclusionHandler defines what a filter of the addresses not counted in the balance statistics should be able to do
+type AddressesExclusionHandler interface {
+	IsExcluded(address string, epoch uint32) bool
+}
+
+// BalanceBucketsHandler defines what a classifier of balances in distribution buckets should be able to do
+type BalanceBucketsHandler interface {
+	NewDistribution() []*data.BalanceBucket
+	AddBalance(distribution []*data.BalanceBucket, balance *big.Int)
+}
+
+// StateStorer defines what a storer of the processors checkpoints should be able to do
+type StateStorer interface {
+	SaveCheckpoint(name string, epoch uint32, state interface{}) error
+	LoadCheckpoint(name string, epoch uint32, state interface{}) error
+	LastCheckpointEpoch(name string, maxEpoch uint32) (uint32, bool, error)
+}
+
+// StakeBalancesStorer defines what a persistent storer of the staked balances of every epoch should be able to do
+type StakeBalancesStorer interface {
+	SaveStakeBalances(epoch uint32, balances map[string]string) error
+	LoadStakeBalances(epoch uint32) (map[string]string, error)
+	HasStakeBalances(epoch uint32) (bool, error)
 }
 
 type AccountsHandler interface {
-	ProcessAllAccounts(endEpoch uint32) ([]byte, error)
+	ProcessAllAccounts(ctx context.Context, startEpoch, endEpoch uint32, resume bool) ([]*data.StatisticsAddressesBalanceEpoch, error)
 }
 
 type TransactionsHandler interface {
-	ProcessAllTxs(endEpoch uint32) ([]byte, error)
+	ProcessAllTxs(ctx context.Context, startEpoch, endEpoch uint32, resume bool) ([]*data.StatisticsEpoch, error)
 }
 
 type StakeInfoHandler interface {
-	ProcessEpochs(endEpoch uint32) ([]byte, error)
+	ProcessEpochs(ctx context.Context, startEpoch, endEpoch uint32, resume bool) ([]*data.StakeInfoEpoch, error)
 }
@@ -10,6 +10,14 @@ import (
 
 type object = map[string]interface{}
 
+const (
+	// the addresses are aggregated on the keyword fields created by the dynamic mapping of the transactions index
+	senderKeywordField   = "sender.keyword"
+	receiverKeywordField = "receiver.keyword"
+
+	addressesAggregationPageSize = 10000
+)
+
 func encodeQuery(query object) (bytes.Buffer, error) {
 	var buff bytes.Buffer
 	if err := json.NewEncoder(&buff).Encode(query); err != nil {
@@ -36,6 +44,86 @@ func getTransactionsByTimestamp(start, stop int) *bytes.Buffer {
 	return &encoded
 }
 
+// transactionsHistogramQuery counts the transactions of the interval on an hourly date histogram, which is not
+// bounded like the total hits of a search
+func transactionsHistogramQuery(start, stop int) *bytes.Buffer {
+	obj := object{
+		"query": timestampRange(start, stop),
+		"aggs": object{
+			"transactions": object{
+				"date_histogram": object{
+					"field":          "timestamp",
+					"fixed_interval": "1h",
+				},
+			},
+		},
+	}
+
+	encoded, _ := encodeQuery(obj)
+
+	return &encoded
+}
+
+// addressesAggregationQuery requests a page of the distinct values of an address field of the transactions of the
+// interval, with their number of transactions and the number of them not sent by the metachain. The first page is
+// requested with an empty afterKey
+func addressesAggregationQuery(start, stop int, field string, afterKey json.RawMessage) *bytes.Buffer {
+	composite := object{
+		"size": addressesAggregationPageSize,
+		"sources": []interface{}{
+			object{
+				"address": object{
+					"terms": object{
+						"field": field,
+					},
+				},
+			},
+		},
+	}
+	if len(afterKey) > 0 {
+		composite["after"] = afterKey
+	}
+
+	obj := object{
+		"query": timestampRange(start, stop),
+		"aggs": object{
+			"addresses": object{
+				"composite": composite,
+				"aggs": object{
+					"notFromMetachain": object{
+						"filter": object{
+							"bool": object{
+								"must_not": []interface{}{
+									object{
+										"term": object{
+											senderKeywordField: fmt.Sprintf("%d", core.MetachainShardId),
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	encoded, _ := encodeQuery(obj)
+
+	return &encoded
+}
+
+func timestampRange(start, stop int) object {
+	return object{
+		"range": object{
+			"timestamp": object{
+				"gte": start,
+				"lte": stop,
+			},
+		},
+	}
+}
+
 func accountsHistoryAddress(start, stop int, addr string) *bytes.Buffer {
 	obj := object{
 		"query": object{
@@ -144,3 +232,63 @@ func getTransactionsToAddr(start, stop int, addr string) *bytes.Buffer {
 
 	return &encoded
 }
+
+func epochStartMetaBlockQuery(epoch uint32) *bytes.Buffer {
+	obj := object{
+		"query": object{
+			"bool": object{
+				"must": []interface{}{
+					object{
+						"match": object{
+							"shardId": core.MetachainShardId,
+						},
+					},
+					object{
+						"match": object{
+							"epoch": epoch,
+						},
+					},
+					object{
+						"match": object{
+							"epochStartBlock": true,
+						},
+					},
+				},
+			},
+		},
+		"sort": []interface{}{
+			object{
+				"timestamp": object{
+					"order": "asc",
+				},
+			},
+		},
+		"size": 1,
+	}
+
+	encoded, _ := encodeQuery(obj)
+
+	return &encoded
+}
+
+func lastMetaBlockQuery() *bytes.Buffer {
+	obj := object{
+		"query": object{
+			"match": object{
+				"shardId": core.MetachainShardId,
+			},
+		},
+		"sort": []interface{}{
+			object{
+				"timestamp": object{
+					"order": "desc",
+				},
+			},
+		},
+		"size": 1,
+	}
+
+	encoded, _ := encodeQuery(obj)
+
+	return &encoded
+}
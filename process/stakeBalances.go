@@ -0,0 +1,80 @@
+package process
+
+import (
+	"fmt"
+	"sync"
+)
+
+// stakeBalancesHolder keeps in memory the staked balances of every user for the processed epochs until the accounts
+// processor used them in the same run. It also saves them in a storer for later runs and for the released epochs
+type stakeBalancesHolder struct {
+	storer          StakeBalancesStorer
+	mutBalances     sync.RWMutex
+	balancesByEpoch map[uint32]map[string]string
+}
+
+// NewStakeBalancesHolder will create a new instance of stakeBalancesHolder
+func NewStakeBalancesHolder(storer StakeBalancesStorer) (*stakeBalancesHolder, error) {
+	if storer == nil {
+		return nil, fmt.Errorf("nil stake balances storer")
+	}
+
+	return &stakeBalancesHolder{
+		storer:          storer,
+		balancesByEpoch: map[uint32]map[string]string{},
+	}, nil
+}
+
+// SetStakeBalances will save the staked balances of the provided epoch. The balances that could not be saved are not
+// kept in memory either, so the epoch has no stake balances for the accounts processor
+func (sbh *stakeBalancesHolder) SetStakeBalances(epoch uint32, balances map[string]string) error {
+	err := sbh.storer.SaveStakeBalances(epoch, balances)
+	if err != nil {
+		return fmt.Errorf("cannot save the stake balances of epoch %d: %w", epoch, err)
+	}
+
+	sbh.mutBalances.Lock()
+	sbh.balancesByEpoch[epoch] = balances
+	sbh.mutBalances.Unlock()
+
+	return nil
+}
+
+// GetStakeBalances returns the staked balances of the provided epoch. If the epoch was not processed in this run,
+// the balances are read from the ones saved by a previous run
+func (sbh *stakeBalancesHolder) GetStakeBalances(epoch uint32) (map[string]string, error) {
+	sbh.mutBalances.RLock()
+	balances, ok := sbh.balancesByEpoch[epoch]
+	sbh.mutBalances.RUnlock()
+	if ok {
+		return balances, nil
+	}
+
+	return sbh.storer.LoadStakeBalances(epoch)
+}
+
+// HasStakeBalances returns true if the staked balances of the provided epoch were processed in this run or saved
+// by a previous run
+func (sbh *stakeBalancesHolder) HasStakeBalances(epoch uint32) (bool, error) {
+	sbh.mutBalances.RLock()
+	_, ok := sbh.balancesByEpoch[epoch]
+	sbh.mutBalances.RUnlock()
+	if ok {
+		return true, nil
+	}
+
+	return sbh.storer.HasStakeBalances(epoch)
+}
+
+// ReleaseStakeBalances removes from memory the staked balances of the provided epoch and of the epochs before it,
+// the accounts processor calls it after it used them. The released epochs are read from the storer when needed again
+func (sbh *stakeBalancesHolder) ReleaseStakeBalances(epoch uint32) {
+	sbh.mutBalances.Lock()
+	defer sbh.mutBalances.Unlock()
+
+	for heldEpoch := range sbh.balancesByEpoch {
+		if heldEpoch <= epoch {
+			delete(sbh.balancesByEpoch, heldEpoch)
+		}
+	}
+}
@@ -1,12 +1,14 @@
 package process
 
 import (
+	"context"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"log"
 	"math/big"
 	"strings"
+	"time"
 
 	dataIndexer "github.com/ElrondNetwork/elastic-indexer-go/data"
 	"github.com/ElrondNetwork/elrond-go/core"
@@ -16,10 +18,28 @@ import (
 
 const (
 	delegationManager = "erd1qqqqqqqqqqqqqqqpqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqylllslmq6y6"
+
+	stakeInfoCheckpointName = "stake"
 )
 
+// stakeInfoProcessorState holds everything the stake info processor accumulates across epochs
+type stakeInfoProcessorState struct {
+	LastEpoch                      uint32              `json:"lastEpoch"`
+	AccumulatedRewardDelegation    *big.Int            `json:"accumulatedRewardDelegation"`
+	ClaimedRewards                 *big.Int            `json:"claimedRewards"`
+	AccumulatedUnJail              *big.Int            `json:"accumulatedUnJail"`
+	DelegationLegacyUsers          map[string]*big.Int `json:"delegationLegacyUsers"`
+	StakingUsers                   map[string]*big.Int `json:"stakingUsers"`
+	Balances                       map[string]*big.Int `json:"balances"`
+	DelegationManagerContractAddrs []string            `json:"delegationManagerContractAddrs"`
+	DelegatorDelegationManager     map[string]*big.Int `json:"delegatorDelegationManager"`
+}
+
 type stakeInfoProcessor struct {
 	elasticHandler                 ElasticHandler
+	stateStorer                    StateStorer
+	stakeBalances                  StakeBalancesHandler
+	buckets                        BalanceBucketsHandler
 	restClient                     RestClientHandler
 	pubKeyConverter                core.PubkeyConverter
 	accumulatedRewardDelegation    *big.Int
@@ -33,90 +53,320 @@ type stakeInfoProcessor struct {
 	delegationContractAddress      string
 	stakingContractAddress         string
 	epoch                          uint32
-	genesisTime                    int
+	epochBoundaries                EpochBoundariesHandler
+	pathGenesisFiles               string
+	checkpointsInterval            uint32
+	epochMetrics                   EpochMetricsHandler
+	indices                        Indices
+	strict                         bool
 
 	delegatorDelegationManager map[string]*big.Int
+	// stateBeforeEpoch restores the state when the processed epoch is discarded
+	stateBeforeEpoch *stakeInfoProcessorState
 }
 
 func NewStakeInfoProcessor(
 	handler ElasticHandler,
+	stateStorer StateStorer,
+	stakeBalances StakeBalancesHandler,
+	buckets BalanceBucketsHandler,
 	restClient RestClientHandler,
 	pubKeyConverter core.PubkeyConverter,
 	pathGenesisFiles string,
-	genesisTime int,
+	epochBoundaries EpochBoundariesHandler,
 	delegationContractAddress string,
 	stakingContractAddress string,
+	checkpointsInterval uint32,
+	epochMetrics EpochMetricsHandler,
+	indices Indices,
+	strict bool,
 ) (*stakeInfoProcessor, error) {
-	genesisAccts, err := genesis.ReadGenesisDelegationLegacyUsers(pathGenesisFiles)
+	if epochMetrics == nil {
+		return nil, fmt.Errorf("nil epoch metrics handler")
+	}
+
+	sip := &stakeInfoProcessor{
+		elasticHandler:            handler,
+		stateStorer:               stateStorer,
+		stakeBalances:             stakeBalances,
+		buckets:                   buckets,
+		restClient:                restClient,
+		pubKeyConverter:           pubKeyConverter,
+		epochBoundaries:           epochBoundaries,
+		stats:                     map[uint32]*data.StakeInfoEpoch{},
+		delegationContractAddress: delegationContractAddress,
+		stakingContractAddress:    stakingContractAddress,
+		pathGenesisFiles:          pathGenesisFiles,
+		checkpointsInterval:       checkpointsInterval,
+		epochMetrics:              epochMetrics,
+		indices:                   indices,
+		strict:                    strict,
+	}
+
+	err := sip.resetState()
 	if err != nil {
 		return nil, err
 	}
 
-	stakingAccts, err := genesis.ReadGenesisStakingUsers("../genesis")
-
-	return &stakeInfoProcessor{
-		elasticHandler:                 handler,
-		restClient:                     restClient,
-		pubKeyConverter:                pubKeyConverter,
-		genesisTime:                    genesisTime,
-		balances:                       map[string]*big.Int{},
-		accumulatedRewardDelegation:    big.NewInt(0),
-		stats:                          map[uint32]*data.StakeInfoEpoch{},
-		claimedRewards:                 big.NewInt(0),
-		accumulatedUnJail:              big.NewInt(0),
-		delegationLegacyUsers:          genesisAccts,
-		stakingUsers:                   stakingAccts,
-		delegationManagerContractAddrs: []string{},
-		delegationContractAddress:      delegationContractAddress,
-		stakingContractAddress:         stakingContractAddress,
-		delegatorDelegationManager:     map[string]*big.Int{},
-	}, nil
-}
-
-func (sip *stakeInfoProcessor) ProcessEpochs(endEpoch uint32) ([]byte, error) {
-	for epoch := uint32(0); epoch < endEpoch; epoch++ {
+	return sip, nil
+}
+
+func (sip *stakeInfoProcessor) ProcessEpochs(ctx context.Context, startEpoch, endEpoch uint32, resume bool) ([]*data.StakeInfoEpoch, error) {
+	firstEpoch, err := sip.loadCheckpoint(startEpoch, resume)
+	if err != nil {
+		return nil, err
+	}
+	if resume {
+		startEpoch = firstEpoch
+	}
+
+	sliceStats := make([]*data.StakeInfoEpoch, 0)
+	unsaved := false
+	for epoch := firstEpoch; epoch < endEpoch; epoch++ {
 		sip.epoch = epoch
-		sip.stats[sip.epoch] = &data.StakeInfoEpoch{}
+		sip.stats[sip.epoch] = &data.StakeInfoEpoch{
+			Epoch: epoch,
+		}
+		sip.stateBeforeEpoch = copyStakeInfoState(sip.currentState(epoch))
 
 		log.Printf("total staking epoch %d \n", epoch)
 
-		err := sip.processEpoch(sip.genesisTime+int(epoch)*secondsInADay, sip.genesisTime+int(epoch+1)*secondsInADay)
+		epochStart := time.Now()
+		err = processWithWindowRestarts(ctx, epoch, sip.processEpoch, sip.restoreStateBefore)
+		if ctx.Err() != nil {
+			// the interrupted epoch is not saved, a run with resume continues with it
+			return sliceStats, sip.saveBeforeStop(epoch, unsaved, ctx.Err())
+		}
+		if epoch >= startEpoch {
+			sip.epochMetrics.EpochProcessed(StatisticStake, time.Since(epochStart), err != nil)
+		}
+		if isHardError(err) || (err != nil && sip.strict) {
+			return sliceStats, sip.saveBeforeStop(epoch, unsaved, fmt.Errorf("process stake info epoch %d: %w", epoch, err))
+		}
 		if err != nil {
 			log.Printf("cannot proccess stake info for epoch %d, error %s", epoch, err.Error())
 		}
+		sip.stats[epoch].Status, sip.stats[epoch].Error = data.EpochStatus(err)
+
+		unsaved = true
+		if isCheckpointEpoch(epoch, endEpoch, sip.checkpointsInterval) {
+			err = sip.saveCheckpoint(epoch)
+			if err != nil {
+				return nil, err
+			}
+			unsaved = false
+		}
+
+		// epochs before the start epoch are only replayed to rebuild the state needed by the cumulative metrics
+		if epoch >= startEpoch {
+			sliceStats = append(sliceStats, sip.stats[sip.epoch])
+		}
 	}
 
-	sliceStats := make([]*data.StakeInfoEpoch, endEpoch)
-	for idx := uint32(0); idx < endEpoch; idx++ {
-		sip.stats[idx].Epoch = idx
-		sliceStats[idx] = sip.stats[idx]
+	return sliceStats, nil
+}
+
+func (sip *stakeInfoProcessor) processEpoch(ctx context.Context) error {
+	start, stop, err := sip.epochBoundaries.EpochBoundaries(ctx, sip.epoch)
+	if err != nil {
+		return err
 	}
 
-	bytes, _ := json.MarshalIndent(sliceStats, "", " ")
-	return bytes, nil
+	return sip.processEpochInterval(ctx, start, stop)
 }
 
-func (sip *stakeInfoProcessor) processEpoch(start, stop int) error {
-	delegationBalance, err := sip.getAddressBalance(start, stop, sip.delegationContractAddress)
+// currentState returns the state accumulated up to the provided epoch, it shares the maps of the processor
+func (sip *stakeInfoProcessor) currentState(epoch uint32) *stakeInfoProcessorState {
+	return &stakeInfoProcessorState{
+		LastEpoch:                      epoch,
+		AccumulatedRewardDelegation:    sip.accumulatedRewardDelegation,
+		ClaimedRewards:                 sip.claimedRewards,
+		AccumulatedUnJail:              sip.accumulatedUnJail,
+		DelegationLegacyUsers:          sip.delegationLegacyUsers,
+		StakingUsers:                   sip.stakingUsers,
+		Balances:                       sip.balances,
+		DelegationManagerContractAddrs: sip.delegationManagerContractAddrs,
+		DelegatorDelegationManager:     sip.delegatorDelegationManager,
+	}
+}
+
+func (sip *stakeInfoProcessor) saveCheckpoint(epoch uint32) error {
+	return sip.stateStorer.SaveCheckpoint(stakeInfoCheckpointName, epoch, sip.currentState(epoch))
+}
+
+// saveBeforeStop saves the state of the epochs completed since the last checkpoint when the run stops at the provided
+// epoch, after discarding what was gathered for it. The returned error is the one that stopped the run
+func (sip *stakeInfoProcessor) saveBeforeStop(epoch uint32, unsaved bool, errStop error) error {
+	if !unsaved {
+		return errStop
+	}
+
+	err := sip.restoreStateBefore(epoch)
+	if err == nil {
+		err = sip.saveCheckpoint(epoch - 1)
+	}
+	if err != nil {
+		log.Printf("cannot save the stake info checkpoint of epoch %d, error %s", epoch-1, err.Error())
+	}
+
+	return errStop
+}
+
+// loadCheckpoint restores the state needed before processing the start epoch and returns the first epoch
+// that has to be processed. Without a suitable checkpoint, the processing has to start from the first epoch
+func (sip *stakeInfoProcessor) loadCheckpoint(startEpoch uint32, resume bool) (uint32, error) {
+	checkpointEpoch, found, err := checkpointToLoad(sip.stateStorer, stakeInfoCheckpointName, startEpoch, resume)
+	if err != nil {
+		return 0, err
+	}
+	if !found {
+		return 0, sip.resetState()
+	}
+
+	err = sip.restoreCheckpoint(checkpointEpoch)
+	if err != nil {
+		return 0, err
+	}
+
+	log.Printf("continue stake info processing from checkpoint of epoch %d \n", checkpointEpoch)
+
+	return checkpointEpoch + 1, nil
+}
+
+func (sip *stakeInfoProcessor) restoreCheckpoint(checkpointEpoch uint32) error {
+	state := &stakeInfoProcessorState{}
+	err := sip.stateStorer.LoadCheckpoint(stakeInfoCheckpointName, checkpointEpoch, state)
 	if err != nil {
 		return err
 	}
 
-	stakingContractBalance, err := sip.getAddressBalance(start, stop, sip.stakingContractAddress)
+	sip.applyState(state)
+
+	return nil
+}
+
+func (sip *stakeInfoProcessor) applyState(state *stakeInfoProcessorState) {
+	sip.accumulatedRewardDelegation = bigIntOrZero(state.AccumulatedRewardDelegation)
+	sip.claimedRewards = bigIntOrZero(state.ClaimedRewards)
+	sip.accumulatedUnJail = bigIntOrZero(state.AccumulatedUnJail)
+	sip.delegationLegacyUsers = bigIntMapOrEmpty(state.DelegationLegacyUsers)
+	sip.stakingUsers = bigIntMapOrEmpty(state.StakingUsers)
+	sip.balances = bigIntMapOrEmpty(state.Balances)
+	sip.delegatorDelegationManager = bigIntMapOrEmpty(state.DelegatorDelegationManager)
+	sip.delegationManagerContractAddrs = state.DelegationManagerContractAddrs
+	if sip.delegationManagerContractAddrs == nil {
+		sip.delegationManagerContractAddrs = []string{}
+	}
+}
+
+// resetState sets the state from before the first epoch, the delegation legacy and staking users from genesis
+func (sip *stakeInfoProcessor) resetState() error {
+	genesisAccts, err := genesis.ReadGenesisDelegationLegacyUsers(sip.pathGenesisFiles)
+	if err != nil {
+		return err
+	}
+
+	stakingAccts, err := genesis.ReadGenesisStakingUsers(sip.pathGenesisFiles)
+	if err != nil {
+		return err
+	}
+
+	sip.accumulatedRewardDelegation = big.NewInt(0)
+	sip.claimedRewards = big.NewInt(0)
+	sip.accumulatedUnJail = big.NewInt(0)
+	sip.delegationLegacyUsers = genesisAccts
+	sip.stakingUsers = stakingAccts
+	sip.balances = map[string]*big.Int{}
+	sip.delegationManagerContractAddrs = []string{}
+	sip.delegatorDelegationManager = map[string]*big.Int{}
+
+	return nil
+}
+
+// restoreStateBefore discards everything gathered while processing the provided epoch, the state gets back to the
+// copy taken before it
+func (sip *stakeInfoProcessor) restoreStateBefore(epoch uint32) error {
+	sip.stats[epoch] = &data.StakeInfoEpoch{
+		Epoch: epoch,
+	}
+
+	if sip.stateBeforeEpoch == nil {
+		return fmt.Errorf("no stake info state before epoch %d", epoch)
+	}
+	sip.applyState(copyStakeInfoState(sip.stateBeforeEpoch))
+
+	return nil
+}
+
+// copyStakeInfoState returns a deep copy of the state, the amounts are changed in place while processing
+func copyStakeInfoState(state *stakeInfoProcessorState) *stakeInfoProcessorState {
+	return &stakeInfoProcessorState{
+		LastEpoch:                      state.LastEpoch,
+		AccumulatedRewardDelegation:    copyBigInt(state.AccumulatedRewardDelegation),
+		ClaimedRewards:                 copyBigInt(state.ClaimedRewards),
+		AccumulatedUnJail:              copyBigInt(state.AccumulatedUnJail),
+		DelegationLegacyUsers:          copyBigIntMap(state.DelegationLegacyUsers),
+		StakingUsers:                   copyBigIntMap(state.StakingUsers),
+		Balances:                       copyBigIntMap(state.Balances),
+		DelegationManagerContractAddrs: append([]string{}, state.DelegationManagerContractAddrs...),
+		DelegatorDelegationManager:     copyBigIntMap(state.DelegatorDelegationManager),
+	}
+}
+
+func copyBigInt(value *big.Int) *big.Int {
+	if value == nil {
+		return nil
+	}
+
+	return big.NewInt(0).Set(value)
+}
+
+func copyBigIntMap(values map[string]*big.Int) map[string]*big.Int {
+	copied := make(map[string]*big.Int, len(values))
+	for key, value := range values {
+		copied[key] = copyBigInt(value)
+	}
+
+	return copied
+}
+
+func bigIntOrZero(value *big.Int) *big.Int {
+	if value == nil {
+		return big.NewInt(0)
+	}
+
+	return value
+}
+
+func bigIntMapOrEmpty(values map[string]*big.Int) map[string]*big.Int {
+	if values == nil {
+		return map[string]*big.Int{}
+	}
+
+	return values
+}
+
+func (sip *stakeInfoProcessor) processEpochInterval(ctx context.Context, start, stop int) error {
+	delegationBalance, err := sip.getAddressBalance(ctx, start, stop, sip.delegationContractAddress)
+	if err != nil {
+		return err
+	}
+
+	stakingContractBalance, err := sip.getAddressBalance(ctx, start, stop, sip.stakingContractAddress)
 	if err != nil {
 		return err
 	}
-	rewardTxValue, err := sip.getRewardTxValueDelegationLegacy(start, stop)
+	rewardTxValue, err := sip.getRewardTxValueDelegationLegacy(ctx, start, stop)
 	if err != nil {
 		return err
 	}
 
-	err = sip.parseTransactionsDelegationLegacyContract(start, stop)
+	err = sip.parseTransactionsDelegationLegacyContract(ctx, start, stop)
 	if err != nil {
 		return err
 	}
 
-	err = sip.parseTransactionStakingContract(start, stop)
+	err = sip.parseTransactionStakingContract(ctx, start, stop)
 	if err != nil {
 		return err
 	}
@@ -159,13 +409,12 @@ func (sip *stakeInfoProcessor) processEpoch(start, stop int) error {
 
 	sip.stats[sip.epoch].Delegation = delegationStake.String()
 
-	DumpBalances(sip.delegationLegacyUsers, sip.stakingUsers, "../reportsV2/balances", sip.epoch)
-	return nil
+	return sip.stakeBalances.SetStakeBalances(sip.epoch, MergeStakeBalances(sip.delegationLegacyUsers, sip.stakingUsers, sip.buckets))
 }
 
-func (sip *stakeInfoProcessor) getAddressBalance(start, stop int, addr string) (*big.Int, error) {
+func (sip *stakeInfoProcessor) getAddressBalance(ctx context.Context, start, stop int, addr string) (*big.Int, error) {
 	queryDelegation := accountsHistoryAddress(start, stop, addr)
-	response, err := sip.elasticHandler.DoSearchRequest(queryDelegation, accountsHistoryIndex)
+	response, err := sip.elasticHandler.DoSearchRequest(ctx, queryDelegation, sip.indices.AccountsHistory)
 	if err != nil {
 		return nil, err
 	}
@@ -191,13 +440,13 @@ func (sip *stakeInfoProcessor) getAddressBalance(start, stop int, addr string) (
 	return stringToBigInt(acct.Balance), nil
 }
 
-func (sip *stakeInfoProcessor) getRewardTxValueDelegationLegacy(start, stop int) (*big.Int, error) {
-	if start == sip.genesisTime {
+func (sip *stakeInfoProcessor) getRewardTxValueDelegationLegacy(ctx context.Context, start, stop int) (*big.Int, error) {
+	if sip.epoch == 0 {
 		return big.NewInt(0), nil
 	}
 
 	queryDelegation := rewardTxQuery(start, stop, sip.delegationContractAddress)
-	response, err := sip.elasticHandler.DoSearchRequest(queryDelegation, transactionsIndex)
+	response, err := sip.elasticHandler.DoSearchRequest(ctx, queryDelegation, sip.indices.Transactions)
 	if err != nil {
 		return nil, err
 	}
@@ -231,10 +480,10 @@ func (sip *stakeInfoProcessor) processAccountsHistoryResponse(responseBytes []by
 	return nil
 }
 
-func (sip *stakeInfoProcessor) parseTransactionsDelegationLegacyContract(start, stop int) error {
+func (sip *stakeInfoProcessor) parseTransactionsDelegationLegacyContract(ctx context.Context, start, stop int) error {
 	getTxs := getTransactionsToAddr(start, stop, sip.delegationContractAddress)
 
-	err := sip.elasticHandler.DoScrollRequestAllDocuments(getTxs, transactionsIndex, func(responseBytes []byte) error {
+	err := sip.elasticHandler.DoScrollRequestAllDocuments(ctx, getTxs, sip.indices.Transactions, func(responseBytes []byte) error {
 		response := &data.ScrollTransactionsSCRS{}
 		errU := json.Unmarshal(responseBytes, response)
 		if errU != nil {
@@ -347,10 +596,10 @@ func (sip *stakeInfoProcessor) processUnStakeLegacy(tx *data.TxWithSCRS) {
 	}
 }
 
-func (sip *stakeInfoProcessor) parseTransactionStakingContract(start, stop int) error {
+func (sip *stakeInfoProcessor) parseTransactionStakingContract(ctx context.Context, start, stop int) error {
 	getTxs := getTransactionsToAddr(start, stop, sip.stakingContractAddress)
 
-	err := sip.elasticHandler.DoScrollRequestAllDocuments(getTxs, transactionsIndex, func(responseBytes []byte) error {
+	err := sip.elasticHandler.DoScrollRequestAllDocuments(ctx, getTxs, sip.indices.Transactions, func(responseBytes []byte) error {
 		response := &data.ScrollTransactionsSCRS{}
 		errU := json.Unmarshal(responseBytes, response)
 		if errU != nil {
@@ -390,12 +639,12 @@ func (sip *stakeInfoProcessor) parseTransactionStakingContract(start, stop int)
 		return nil
 	}
 
-	sip.delegationManagerContractAddrs, err = sip.getAllDelegationManagerContracts()
+	sip.delegationManagerContractAddrs, err = sip.getAllDelegationManagerContracts(ctx)
 	if err != nil {
 		return err
 	}
 
-	err = sip.processTxsToDelegationManagerCreator(start, stop)
+	err = sip.processTxsToDelegationManagerCreator(ctx, start, stop)
 	if err != nil {
 		return err
 	}
@@ -403,7 +652,7 @@ func (sip *stakeInfoProcessor) parseTransactionStakingContract(start, stop int)
 	for _, contractAddr := range sip.delegationManagerContractAddrs {
 		getTxsDelegation := getTransactionsToAddr(start, stop, contractAddr)
 
-		errSCR := sip.elasticHandler.DoScrollRequestAllDocuments(getTxsDelegation, transactionsIndex, func(responseBytes []byte) error {
+		errSCR := sip.elasticHandler.DoScrollRequestAllDocuments(ctx, getTxsDelegation, sip.indices.Transactions, func(responseBytes []byte) error {
 			response := &data.ScrollTransactionsSCRS{}
 			errU := json.Unmarshal(responseBytes, response)
 			if errU != nil {
@@ -544,7 +793,7 @@ func (sip *stakeInfoProcessor) processUnBond(tx *data.TxWithSCRS) {
 	}
 }
 
-func (sip *stakeInfoProcessor) getAllDelegationManagerContracts() ([]string, error) {
+func (sip *stakeInfoProcessor) getAllDelegationManagerContracts(ctx context.Context) ([]string, error) {
 	vmRequest := &data.VmValueRequest{
 		Address:    delegationManager,
 		FuncName:   "getAllContractAddresses",
@@ -552,7 +801,7 @@ func (sip *stakeInfoProcessor) getAllDelegationManagerContracts() ([]string, err
 	}
 
 	responseVmValue := &data.ResponseVmValue{}
-	err := sip.restClient.CallPostRestEndPoint("/vm-values/query", vmRequest, responseVmValue)
+	err := sip.restClient.CallPostRestEndPoint(ctx, "/vm-values/query", vmRequest, responseVmValue)
 	if err != nil {
 		return nil, err
 	}
@@ -569,10 +818,10 @@ func (sip *stakeInfoProcessor) getAllDelegationManagerContracts() ([]string, err
 	return encodedAddrs, nil
 }
 
-func (sip *stakeInfoProcessor) processTxsToDelegationManagerCreator(start, stop int) error {
+func (sip *stakeInfoProcessor) processTxsToDelegationManagerCreator(ctx context.Context, start, stop int) error {
 	getTxs := getTransactionsToAddr(start, stop, delegationManager)
 
-	err := sip.elasticHandler.DoScrollRequestAllDocuments(getTxs, transactionsIndex, func(responseBytes []byte) error {
+	err := sip.elasticHandler.DoScrollRequestAllDocuments(ctx, getTxs, sip.indices.Transactions, func(responseBytes []byte) error {
 		response := &data.ScrollTransactionsSCRS{}
 		errU := json.Unmarshal(responseBytes, response)
 		if errU != nil {
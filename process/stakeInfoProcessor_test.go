@@ -1,24 +1,136 @@
 package process
 
 import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
 	"testing"
 
 	"github.com/ElrondNetwork/elrond-go/config"
 	"github.com/ElrondNetwork/elrond-go/data/state/factory"
+	"github.com/ElrondNetwork/statistics-go/data"
 	"github.com/ElrondNetwork/statistics-go/elasticClient"
 	"github.com/ElrondNetwork/statistics-go/restClient"
+	"github.com/ElrondNetwork/statistics-go/retry"
+	"github.com/ElrondNetwork/statistics-go/state"
 	"github.com/elastic/go-elasticsearch/v7"
 )
 
 func TestStakeInfoProcessor_ProcessEpochs(t *testing.T) {
+	retryPolicy, _ := retry.NewPolicy(1, 0, 0, nil)
 	elsaticC, _ := elasticClient.NewElasticClient(elasticsearch.Config{
 		Addresses: []string{"http://localhost:9200"},
-	})
-	restClientt, _ := restClient.NewRestClient("https://gateway.elrond.com")
+	}, 0, retryPolicy, 1, elasticClient.PaginationScroll)
+	restClientt, _ := restClient.NewRestClient("https://gateway.elrond.com", 0, retryPolicy)
 	pubKeyConverter, _ := factory.NewPubkeyConverter(config.PubkeyConfig{Type: "bech32", Length: 32})
 
-	ap, _ := NewStakeInfoProcessor(elsaticC, restClientt, pubKeyConverter, "../genesis", 1596117600, "erd1qqqqqqqqqqqqqpgqxwakt2g7u9atsnr03gqcgmhcv38pt7mkd94q6shuwt", "erd1qqqqqqqqqqqqqqqpqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqplllst77y4l")
+	stateStorer, _ := state.NewFileStorer(t.TempDir(), 0, 0)
+	epochBoundaries, _ := NewFixedEpochBoundaries(1596117600)
+	balancesStorer, _ := state.NewBalancesFileStorer(t.TempDir())
+	stakeBalances, _ := NewStakeBalancesHolder(balancesStorer)
+	buckets, _ := NewBalanceBuckets([]string{"0.1", "1", "10", "100", "1000"})
+
+	ap, _ := NewStakeInfoProcessor(elsaticC, stateStorer, stakeBalances, buckets, restClientt, pubKeyConverter, "../genesis", epochBoundaries, "erd1qqqqqqqqqqqqqpgqxwakt2g7u9atsnr03gqcgmhcv38pt7mkd94q6shuwt", "erd1qqqqqqqqqqqqqqqpqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqplllst77y4l", 0, NewDisabledEpochMetrics(), testIndices, false)
 
-	_, _ = ap.ProcessEpochs(50)
+	_, _ = ap.ProcessEpochs(context.Background(), 0, 50, false)
 	//ap.getAllDelegationManagerContracts()
 }
+
+const (
+	testDelegationContract = "erd1qqqqqqqqqqqqqpgqxwakt2g7u9atsnr03gqcgmhcv38pt7mkd94q6shuwt"
+	testStakingContract    = "erd1qqqqqqqqqqqqqqqpqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqplllst77y4l"
+)
+
+// newStakeInfoProcessorFromCheckpoint creates a stake info processor with the checkpoint of epoch 5, for which every
+// search returns the balance of the contracts and the reward transaction of the epoch
+func newStakeInfoProcessorFromCheckpoint(t *testing.T, balancesStorer StakeBalancesStorer, strict bool) (*stakeInfoProcessor, *checkpointStorerStub) {
+	pubKeyConverter, _ := factory.NewPubkeyConverter(config.PubkeyConfig{Type: "bech32", Length: 32})
+	stateStorer := newCheckpointStorerStub(t, 5, &stakeInfoProcessorState{
+		LastEpoch:                   5,
+		AccumulatedRewardDelegation: big.NewInt(10),
+		ClaimedRewards:              big.NewInt(0),
+		AccumulatedUnJail:           big.NewInt(0),
+		StakingUsers:                map[string]*big.Int{"erd1staker": big.NewInt(2500)},
+		Balances:                    map[string]*big.Int{testDelegationContract: big.NewInt(100), testStakingContract: big.NewInt(2500)},
+	})
+	epochBoundaries, _ := NewFixedEpochBoundaries(1596117600)
+	stakeBalances, _ := NewStakeBalancesHolder(balancesStorer)
+	buckets, _ := NewBalanceBuckets([]string{"0.1", "1"})
+	handler := &emptyElasticStub{elasticSearchStub{response: []byte(`{"hits":{"hits":[{"_source":{"balance":"100","value":"5"}}]}}`)}}
+
+	sip, _ := NewStakeInfoProcessor(handler, stateStorer, stakeBalances, buckets, &restClientStub{}, pubKeyConverter, "../genesis", epochBoundaries, testDelegationContract, testStakingContract, 0, NewDisabledEpochMetrics(), testIndices, strict)
+
+	return sip, stateStorer
+}
+
+func TestStakeInfoProcessor_ResumeContinuesFromTheCheckpoint(t *testing.T) {
+	balancesStorer, _ := state.NewBalancesFileStorer(t.TempDir())
+	sip, stateStorer := newStakeInfoProcessorFromCheckpoint(t, balancesStorer, true)
+
+	records, err := sip.ProcessEpochs(context.Background(), 0, 8, true)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if stateStorer.loadedEpoch != 5 {
+		t.Fatalf("expected the checkpoint of epoch 5, got %d", stateStorer.loadedEpoch)
+	}
+	if len(records) != 2 || records[0].Epoch != 6 || records[1].Epoch != 7 {
+		t.Fatalf("expected the records of epochs 6 and 7, got %d records", len(records))
+	}
+
+	// the rewards of the delegation contract keep accumulating over the restored ones
+	if records[0].LegacyDelegation != "85" || records[1].LegacyDelegation != "80" {
+		t.Fatalf("expected the legacy delegation 85 and 80, got %s and %s", records[0].LegacyDelegation, records[1].LegacyDelegation)
+	}
+	if records[1].StakingUsers != 1 || records[1].TotalUniqueUsers != 1 {
+		t.Fatalf("expected the restored staking user, got %d staking users, %d unique users", records[1].StakingUsers, records[1].TotalUniqueUsers)
+	}
+}
+
+// failingBalancesStorerStub cannot save any stake balances
+type failingBalancesStorerStub struct {
+}
+
+func (fbs *failingBalancesStorerStub) SaveStakeBalances(_ uint32, _ map[string]string) error {
+	return fmt.Errorf("disk full")
+}
+
+func (fbs *failingBalancesStorerStub) LoadStakeBalances(epoch uint32) (map[string]string, error) {
+	return nil, fmt.Errorf("no stake balances for epoch %d", epoch)
+}
+
+func (fbs *failingBalancesStorerStub) HasStakeBalances(_ uint32) (bool, error) {
+	return false, nil
+}
+
+func TestStakeInfoProcessor_UnsavedStakeBalancesFailTheEpoch(t *testing.T) {
+	sip, _ := newStakeInfoProcessorFromCheckpoint(t, &failingBalancesStorerStub{}, false)
+
+	records, err := sip.ProcessEpochs(context.Background(), 0, 8, true)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(records))
+	}
+	for _, record := range records {
+		if record.Status != data.EpochStatusFailed || !strings.Contains(record.Error, "disk full") {
+			t.Fatalf("expected epoch %d with the failed status, got %s (%s)", record.Epoch, record.Status, record.Error)
+		}
+	}
+
+	exists, _ := sip.stakeBalances.HasStakeBalances(6)
+	if exists {
+		t.Fatal("expected no stake balances for the failed epoch 6")
+	}
+}
+
+func TestStakeInfoProcessor_UnsavedStakeBalancesStopTheStrictRun(t *testing.T) {
+	sip, _ := newStakeInfoProcessorFromCheckpoint(t, &failingBalancesStorerStub{}, true)
+
+	records, err := sip.ProcessEpochs(context.Background(), 0, 8, true)
+	if err == nil || len(records) != 0 {
+		t.Fatalf("expected the run to stop at epoch 6, got %d records, error %v", len(records), err)
+	}
+}
@@ -1,5 +1,11 @@
 package process
 
+import (
+	"context"
+
+	"github.com/ElrondNetwork/statistics-go/data"
+)
+
 type statisticsProcessor struct {
 	transactionsHandler TransactionsHandler
 	accountsHandler     AccountsHandler
@@ -18,14 +24,14 @@ func NewStatisticsProcessor(
 	}, nil
 }
 
-func (sp *statisticsProcessor) ProcessAllAccounts(endEpoch uint32) ([]byte, error) {
-	return sp.accountsHandler.ProcessAllAccounts(endEpoch)
+func (sp *statisticsProcessor) ProcessAllAccounts(ctx context.Context, startEpoch, endEpoch uint32, resume bool) ([]*data.StatisticsAddressesBalanceEpoch, error) {
+	return sp.accountsHandler.ProcessAllAccounts(ctx, startEpoch, endEpoch, resume)
 }
 
-func (sp *statisticsProcessor) ProcessAllTransactions(endEpoch uint32) ([]byte, error) {
-	return sp.transactionsHandler.ProcessAllTxs(endEpoch)
+func (sp *statisticsProcessor) ProcessAllTransactions(ctx context.Context, startEpoch, endEpoch uint32, resume bool) ([]*data.StatisticsEpoch, error) {
+	return sp.transactionsHandler.ProcessAllTxs(ctx, startEpoch, endEpoch, resume)
 }
 
-func (sp *statisticsProcessor) ProcessStakeInfo(endEpoch uint32) ([]byte, error) {
-	return sp.stakeInfoHandler.ProcessEpochs(endEpoch)
+func (sp *statisticsProcessor) ProcessStakeInfo(ctx context.Context, startEpoch, endEpoch uint32, resume bool) ([]*data.StakeInfoEpoch, error) {
+	return sp.stakeInfoHandler.ProcessEpochs(ctx, startEpoch, endEpoch, resume)
 }
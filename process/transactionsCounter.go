@@ -0,0 +1,324 @@
+package process
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"strings"
+	"time"
+
+	dataIndexer "github.com/ElrondNetwork/elastic-indexer-go/data"
+	"github.com/ElrondNetwork/elrond-go/core"
+	dataTx "github.com/ElrondNetwork/elrond-go/data/transaction"
+	"github.com/ElrondNetwork/statistics-go/data"
+)
+
+// transactionsCounter counts the transactions of a single epoch. It does not depend on the previous epochs, so the
+// epochs can be counted concurrently. The addresses seen in the epoch are kept for the new addresses detection,
+// which needs the addresses of all the previous epochs and is done when the epochs are merged in order
+type transactionsCounter struct {
+	epoch                 uint32
+	elasticHandler        ElasticHandler
+	epochBoundaries       EpochBoundariesHandler
+	pubKeyConverter       core.PubkeyConverter
+	topAddressesCount     int
+	activeAddressesFolder string
+	scrollSlices          int
+	countingMode          string
+	transactionsIndex     string
+
+	stats                *data.StatisticsEpoch
+	dailyActiveAccounts  map[string]int
+	dailyActiveContracts map[string]int
+	// seenAddresses holds the addresses that would be new if not seen before, true for the smart contracts
+	seenAddresses map[string]bool
+
+	// aggregated is the same epoch counted with aggregations, in the compare counting mode
+	aggregated          *transactionsCounter
+	scanDuration        time.Duration
+	aggregationDuration time.Duration
+}
+
+// activeAddressesEpoch is the full dump of the active addresses and contracts of an epoch
+type activeAddressesEpoch struct {
+	Epoch     uint32         `json:"epoch"`
+	Addresses map[string]int `json:"addresses"`
+	Contracts map[string]int `json:"contracts"`
+}
+
+func (tp *transactionsProc) newTransactionsCounter(epoch uint32) *transactionsCounter {
+	tc := &transactionsCounter{
+		epoch:                 epoch,
+		elasticHandler:        tp.elasticHandler,
+		epochBoundaries:       tp.epochBoundaries,
+		pubKeyConverter:       tp.pubKeyConverter,
+		topAddressesCount:     tp.topAddressesCount,
+		activeAddressesFolder: tp.activeAddressesFolder,
+		scrollSlices:          tp.scrollSlices,
+		countingMode:          tp.countingMode,
+		transactionsIndex:     tp.transactionsIndex,
+	}
+	tc.reset(epoch)
+
+	return tc
+}
+
+// reset discards everything counted for the epoch
+func (tc *transactionsCounter) reset(epoch uint32) error {
+	tc.stats = &data.StatisticsEpoch{
+		Epoch: epoch,
+	}
+	tc.dailyActiveAccounts = make(map[string]int)
+	tc.dailyActiveContracts = make(map[string]int)
+	tc.seenAddresses = make(map[string]bool)
+	tc.aggregated = nil
+
+	return nil
+}
+
+func (tc *transactionsCounter) countEpoch(ctx context.Context) error {
+	start, stop, err := tc.epochBoundaries.EpochBoundaries(ctx, tc.epoch)
+	if err != nil {
+		return err
+	}
+
+	switch tc.countingMode {
+	case TransactionsCountingAggregation:
+		err = tc.countWithAggregations(ctx, start, stop)
+	case TransactionsCountingCompare:
+		err = tc.countWithBoth(ctx, start, stop)
+	default:
+		err = tc.countWithScan(ctx, start, stop)
+	}
+	if err != nil {
+		return err
+	}
+
+	tc.stats.SetInfoAboutDailyAccounts(tc.dailyActiveAccounts, tc.topAddressesCount)
+	tc.stats.SetInfoAboutDailyContracts(tc.dailyActiveContracts, tc.topAddressesCount)
+
+	return tc.dumpActiveAddresses()
+}
+
+// countWithScan reads every transaction of the interval, including the data field of the relayed transactions
+func (tc *transactionsCounter) countWithScan(ctx context.Context, start, stop int) error {
+	query := getTransactionsByTimestamp(start, stop)
+	if tc.scrollSlices > 1 {
+		return tc.elasticHandler.DoSlicedScrollRequestAllDocuments(ctx, query, tc.transactionsIndex, tc.scrollSlices, tc.processTransactionsResponse)
+	}
+
+	return tc.elasticHandler.DoScrollRequestAllDocuments(ctx, query, tc.transactionsIndex, tc.processTransactionsResponse)
+}
+
+// countWithAggregations reads the number of transactions and the distinct senders and receivers of the interval
+// instead of the documents. The inner transactions of the relayed transactions are not counted, since they are only
+// in the data field
+func (tc *transactionsCounter) countWithAggregations(ctx context.Context, start, stop int) error {
+	responseBytes, err := tc.elasticHandler.DoAggregationRequest(ctx, transactionsHistogramQuery(start, stop), tc.transactionsIndex)
+	if err != nil {
+		return err
+	}
+
+	histogram := &data.TransactionsHistogramAggregation{}
+	err = json.Unmarshal(responseBytes, histogram)
+	if err != nil {
+		return err
+	}
+	for _, bucket := range histogram.Transactions.Buckets {
+		tc.stats.DailyTransactions += bucket.DocCount
+	}
+
+	err = tc.aggregateAddresses(ctx, start, stop, senderKeywordField, tc.processSenderBucket)
+	if err != nil {
+		return err
+	}
+
+	return tc.aggregateAddresses(ctx, start, stop, receiverKeywordField, tc.processReceiverBucket)
+}
+
+// countWithBoth keeps the scan results of the epoch and the aggregation results next to them for the comparison
+func (tc *transactionsCounter) countWithBoth(ctx context.Context, start, stop int) error {
+	scanStart := time.Now()
+	err := tc.countWithScan(ctx, start, stop)
+	if err != nil {
+		return err
+	}
+	tc.scanDuration = time.Since(scanStart)
+
+	aggregated := &transactionsCounter{
+		epoch:             tc.epoch,
+		elasticHandler:    tc.elasticHandler,
+		epochBoundaries:   tc.epochBoundaries,
+		pubKeyConverter:   tc.pubKeyConverter,
+		topAddressesCount: tc.topAddressesCount,
+		countingMode:      TransactionsCountingAggregation,
+		transactionsIndex: tc.transactionsIndex,
+	}
+	_ = aggregated.reset(tc.epoch)
+
+	aggregationStart := time.Now()
+	err = aggregated.countWithAggregations(ctx, start, stop)
+	if err != nil {
+		return err
+	}
+	tc.aggregationDuration = time.Since(aggregationStart)
+	tc.aggregated = aggregated
+
+	return nil
+}
+
+// aggregateAddresses passes every distinct value of the address field to the provided handler, page by page
+func (tc *transactionsCounter) aggregateAddresses(
+	ctx context.Context,
+	start, stop int,
+	field string,
+	handleBucket func(bucket *data.AddressBucket),
+) error {
+	var afterKey json.RawMessage
+	for {
+		query := addressesAggregationQuery(start, stop, field, afterKey)
+		responseBytes, err := tc.elasticHandler.DoAggregationRequest(ctx, query, tc.transactionsIndex)
+		if err != nil {
+			return err
+		}
+
+		page := &data.AddressesAggregation{}
+		err = json.Unmarshal(responseBytes, page)
+		if err != nil {
+			return err
+		}
+
+		for _, bucket := range page.Addresses.Buckets {
+			handleBucket(bucket)
+		}
+
+		if len(page.Addresses.Buckets) < addressesAggregationPageSize || len(page.Addresses.AfterKey) == 0 {
+			return nil
+		}
+		afterKey = page.Addresses.AfterKey
+	}
+}
+
+// processSenderBucket counts a sender like setMetricForATx does, the metachain is not an account
+func (tc *transactionsCounter) processSenderBucket(bucket *data.AddressBucket) {
+	if bucket.NotFromMetachain.DocCount == 0 {
+		return
+	}
+
+	tc.dailyActiveAccounts[bucket.Key.Address] += bucket.DocCount
+	tc.seeAddress(bucket.Key.Address, false)
+}
+
+// processReceiverBucket counts a receiver like setMetricForATx does, the receivers of the transactions sent by the
+// metachain are counted as contracts but are not seen
+func (tc *transactionsCounter) processReceiverBucket(bucket *data.AddressBucket) {
+	decodedReceiver, _ := tc.pubKeyConverter.Decode(bucket.Key.Address)
+	isSCAddr := core.IsSmartContractAddress(decodedReceiver)
+	if isSCAddr {
+		tc.dailyActiveContracts[bucket.Key.Address] += bucket.DocCount
+		tc.stats.DailyContractCalls += bucket.DocCount
+	}
+
+	if bucket.NotFromMetachain.DocCount > 0 {
+		tc.seeAddress(bucket.Key.Address, isSCAddr)
+	}
+}
+
+// dumpActiveAddresses will write all the active addresses of the epoch in a separate file, if a folder is configured
+func (tc *transactionsCounter) dumpActiveAddresses() error {
+	if tc.activeAddressesFolder == "" {
+		return nil
+	}
+
+	bytes, err := json.Marshal(&activeAddressesEpoch{
+		Epoch:     tc.epoch,
+		Addresses: tc.dailyActiveAccounts,
+		Contracts: tc.dailyActiveContracts,
+	})
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path.Join(tc.activeAddressesFolder, fmt.Sprintf("epoch%d.json", tc.epoch)), bytes, 0644)
+}
+
+func (tc *transactionsCounter) processTransactionsResponse(responseBytes []byte) error {
+	txsResponse := data.ScrollTransactionsResponse{}
+	err := json.Unmarshal(responseBytes, &txsResponse)
+	if err != nil {
+		return err
+	}
+
+	for _, txRes := range txsResponse.Hits.Hits {
+		tc.setMetricForATx(txRes.Tx)
+		tc.checkRelayedTx(txRes.Tx)
+	}
+
+	return nil
+}
+
+func (tc *transactionsCounter) setMetricForATx(tx dataIndexer.Transaction) {
+	if tx.Sender != fmt.Sprintf("%d", core.MetachainShardId) {
+		tc.dailyActiveAccounts[tx.Sender]++
+	}
+
+	decodedReceiver, _ := tc.pubKeyConverter.Decode(tx.Receiver)
+	isSCAddr := core.IsSmartContractAddress(decodedReceiver)
+	if isSCAddr {
+		tc.dailyActiveContracts[tx.Receiver]++
+		tc.stats.DailyContractCalls++
+	}
+
+	tc.stats.DailyTransactions++
+
+	if tx.Sender == fmt.Sprintf("%d", core.MetachainShardId) {
+		return
+	}
+
+	tc.seeAddress(tx.Sender, false)
+	tc.seeAddress(tx.Receiver, isSCAddr)
+}
+
+func (tc *transactionsCounter) checkRelayedTx(txx dataIndexer.Transaction) {
+	txData := string(txx.Data)
+	if txData == "" {
+		return
+	}
+	if txx.Status == "fail" {
+		return
+	}
+
+	if !strings.HasPrefix(txData, core.RelayedTransaction) {
+		return
+	}
+
+	splitData := strings.Split(txData, "@")
+	if len(splitData) < 2 {
+		return
+	}
+
+	innerTx := &dataTx.Transaction{}
+	err := json.Unmarshal([]byte(splitData[1]), innerTx)
+	if err != nil {
+		return
+	}
+
+	senderBech32 := tc.pubKeyConverter.Encode(innerTx.SndAddr)
+	receiverBech32 := tc.pubKeyConverter.Encode(innerTx.RcvAddr)
+
+	tc.dailyActiveAccounts[senderBech32]++
+
+	isSCAddr := core.IsSmartContractAddress(innerTx.RcvAddr)
+	if isSCAddr {
+		tc.dailyActiveContracts[receiverBech32]++
+		tc.stats.DailyContractCalls++
+	}
+
+	tc.seeAddress(receiverBech32, isSCAddr)
+}
+
+func (tc *transactionsCounter) seeAddress(address string, isSCAddr bool) {
+	tc.seenAddresses[address] = tc.seenAddresses[address] || isSCAddr
+}
@@ -1,192 +1,363 @@
 package process
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"log"
-	"strings"
+	"sync"
+	"time"
 
-	dataIndexer "github.com/ElrondNetwork/elastic-indexer-go/data"
 	"github.com/ElrondNetwork/elrond-go/core"
-	dataTx "github.com/ElrondNetwork/elrond-go/data/transaction"
 	"github.com/ElrondNetwork/statistics-go/data"
 	"github.com/ElrondNetwork/statistics-go/genesis"
 )
 
 const (
 	secondsInADay = 24 * 3600
+
+	transactionsCheckpointName = "transactions"
 )
 
+const (
+	// TransactionsCountingScan reads every transaction of an epoch, it is exact and counts the relayed transactions
+	TransactionsCountingScan = "scan"
+	// TransactionsCountingAggregation reads the number of transactions and the distinct senders and receivers of an
+	// epoch with aggregations, it is faster but does not count the inner transactions of the relayed transactions
+	TransactionsCountingAggregation = "aggregation"
+	// TransactionsCountingCompare counts every epoch in both modes, keeps the scan results and reports the differences
+	TransactionsCountingCompare = "compare"
+)
+
+// transactionsProcessorState holds everything the transactions processor accumulates across epochs
+type transactionsProcessorState struct {
+	LastEpoch uint32              `json:"lastEpoch"`
+	Addresses map[string]struct{} `json:"addresses"`
+}
+
 type transactionsProc struct {
 	pubKeyConverter core.PubkeyConverter
 	elasticHandler  ElasticHandler
-	genesisTime     int
+	stateStorer     StateStorer
+	epochBoundaries EpochBoundariesHandler
 	addresses       map[string]struct{}
-	stats           map[uint32]*data.StatisticsEpoch
 	epoch           uint32
 
-	dailyActiveAccounts  map[string]int
-	dailyActiveContracts map[string]int
+	topAddressesCount     int
+	activeAddressesFolder string
+	pathToGenesisFiles    string
+	workers               int
+	scrollSlices          int
+	countingMode          string
+	comparisonReportPath  string
+	checkpointsInterval   uint32
+	epochMetrics          EpochMetricsHandler
+	transactionsIndex     string
+	comparisons           []*countingComparison
+	strict                bool
+}
+
+// countingJob is an epoch handed to a worker, the counting result is sent on done and the counting duration is
+// set before it
+type countingJob struct {
+	counter  *transactionsCounter
+	duration time.Duration
+	done     chan error
 }
 
+// NewTransactionsProcessor will create a new instance of transactionsProc. The transactions of up to workers epochs
+// are counted at the same time, the transactions of every epoch are read with a scroll split in scrollSlices slices.
+// The counting mode selects between scanning the transactions and aggregations, in the compare mode the differences
+// between the two are written in the comparison report
 func NewTransactionsProcessor(
 	elasticHandler ElasticHandler,
+	stateStorer StateStorer,
 	pubKeyConverter core.PubkeyConverter,
 	pathToGenesisFiles string,
-	genesisTime int,
+	epochBoundaries EpochBoundariesHandler,
+	topAddressesCount int,
+	activeAddressesFolder string,
+	workers int,
+	scrollSlices int,
+	countingMode string,
+	comparisonReportPath string,
+	checkpointsInterval uint32,
+	epochMetrics EpochMetricsHandler,
+	indices Indices,
+	strict bool,
 ) (*transactionsProc, error) {
-	addresses, err := genesis.ReadGenesisAddresses(pathToGenesisFiles)
+	if topAddressesCount < 0 {
+		return nil, fmt.Errorf("invalid number of top addresses: %d", topAddressesCount)
+	}
+	if workers < 1 {
+		return nil, fmt.Errorf("invalid number of workers: %d", workers)
+	}
+	if scrollSlices < 1 {
+		return nil, fmt.Errorf("invalid number of scroll slices: %d", scrollSlices)
+	}
+	if epochMetrics == nil {
+		return nil, fmt.Errorf("nil epoch metrics handler")
+	}
+	switch countingMode {
+	case TransactionsCountingScan, TransactionsCountingAggregation, "":
+	case TransactionsCountingCompare:
+		if comparisonReportPath == "" {
+			return nil, fmt.Errorf("the %s counting mode needs a comparison report file", TransactionsCountingCompare)
+		}
+	default:
+		return nil, fmt.Errorf("invalid transactions counting mode %s, expected %s, %s or %s",
+			countingMode, TransactionsCountingScan, TransactionsCountingAggregation, TransactionsCountingCompare)
+	}
+
+	tp := &transactionsProc{
+		pubKeyConverter:       pubKeyConverter,
+		elasticHandler:        elasticHandler,
+		stateStorer:           stateStorer,
+		epoch:                 0,
+		epochBoundaries:       epochBoundaries,
+		topAddressesCount:     topAddressesCount,
+		activeAddressesFolder: activeAddressesFolder,
+		pathToGenesisFiles:    pathToGenesisFiles,
+		workers:               workers,
+		scrollSlices:          scrollSlices,
+		countingMode:          countingMode,
+		comparisonReportPath:  comparisonReportPath,
+		checkpointsInterval:   checkpointsInterval,
+		epochMetrics:          epochMetrics,
+		transactionsIndex:     indices.Transactions,
+		strict:                strict,
+	}
+
+	err := tp.resetState()
 	if err != nil {
 		return nil, err
 	}
 
-	return &transactionsProc{
-		pubKeyConverter:      pubKeyConverter,
-		elasticHandler:       elasticHandler,
-		addresses:            addresses,
-		stats:                map[uint32]*data.StatisticsEpoch{},
-		epoch:                0,
-		dailyActiveContracts: make(map[string]int),
-		dailyActiveAccounts:  make(map[string]int),
-		genesisTime:          genesisTime,
-	}, nil
+	return tp, nil
 }
 
-func (tp *transactionsProc) ProcessAllTxs(endEpoch uint32) ([]byte, error) {
-	for epoch := uint32(0); epoch < endEpoch; epoch++ {
-		log.Printf("process transactions epoch %d \n", epoch)
-		tp.epoch = epoch
-		tp.stats[tp.epoch] = &data.StatisticsEpoch{}
+// ProcessAllTxs counts the transactions of the epochs concurrently and merges the counted epochs in order, since
+// the new addresses of an epoch depend on the addresses of all the previous epochs
+func (tp *transactionsProc) ProcessAllTxs(ctx context.Context, startEpoch, endEpoch uint32, resume bool) ([]*data.StatisticsEpoch, error) {
+	firstEpoch, err := tp.loadCheckpoint(startEpoch, resume)
+	if err != nil {
+		return nil, err
+	}
+	if resume {
+		startEpoch = firstEpoch
+	}
+	tp.comparisons = make([]*countingComparison, 0)
+
+	countingCtx, cancelCounting := context.WithCancel(ctx)
+	jobs, waitCounting := tp.countEpochs(countingCtx, firstEpoch, endEpoch)
+	defer func() {
+		cancelCounting()
+		waitCounting()
+	}()
 
-		err := tp.processTransactionsEpoch(tp.genesisTime+int(epoch)*secondsInADay, tp.genesisTime+int(epoch+1)*secondsInADay)
+	sliceStats := make([]*data.StatisticsEpoch, 0)
+	unsaved := false
+	for job := range jobs {
+		err = <-job.done
+		epoch := job.counter.epoch
+		if ctx.Err() != nil {
+			// the interrupted epoch is not saved, a run with resume continues with it
+			return sliceStats, tp.saveBeforeStop(unsaved, ctx.Err())
+		}
+		if epoch >= startEpoch {
+			tp.epochMetrics.EpochProcessed(StatisticTransactions, job.duration, err != nil)
+		}
+		if isHardError(err) || (err != nil && tp.strict) {
+			return sliceStats, tp.saveBeforeStop(unsaved, fmt.Errorf("process transactions epoch %d: %w", epoch, err))
+		}
 		if err != nil {
 			log.Printf("process transaction epoch %d, error: %s", epoch, err.Error())
 		}
-	}
 
-	sliceStats := make([]*data.StatisticsEpoch, endEpoch)
-	for idx := uint32(0); idx < endEpoch; idx++ {
-		tp.stats[idx].Epoch = idx
-		sliceStats[idx] = tp.stats[idx]
-	}
+		// the counts of a failed epoch are partial, so they are not compared
+		if job.counter.aggregated != nil && err == nil {
+			errCompare := tp.addComparison(tp.compareCountings(job.counter))
+			if errCompare != nil {
+				return sliceStats, tp.saveBeforeStop(unsaved, errCompare)
+			}
+		}
+
+		record := tp.mergeNewAddresses(job.counter)
+		record.Status, record.Error = data.EpochStatus(err)
+
+		tp.epoch = epoch
+		unsaved = true
+		if isCheckpointEpoch(epoch, endEpoch, tp.checkpointsInterval) {
+			err = tp.saveCheckpoint()
+			if err != nil {
+				return nil, err
+			}
+			unsaved = false
+		}
 
-	bytes, _ := json.MarshalIndent(sliceStats, "", " ")
+		// epochs before the start epoch are only replayed to rebuild the state needed by the cumulative metrics
+		if epoch >= startEpoch {
+			sliceStats = append(sliceStats, record)
+		}
+	}
 
-	return bytes, nil
+	return sliceStats, nil
 }
 
-func (tp *transactionsProc) processTransactionsEpoch(startTime, endTime int) error {
-	defer func() {
-		tp.dailyActiveAccounts = make(map[string]int)
-		tp.dailyActiveContracts = make(map[string]int)
+// countEpochs hands the epochs in order to at most tp.workers workers at a time and returns the jobs in the same
+// order. The workers stop when ctx is canceled, the returned function waits for all of them
+func (tp *transactionsProc) countEpochs(ctx context.Context, firstEpoch, endEpoch uint32) (<-chan *countingJob, func()) {
+	// a job is started only after it was queued, the one being merged is out of the queue
+	jobs := make(chan *countingJob, tp.workers-1)
+	dispatcherDone := make(chan struct{})
+	wg := &sync.WaitGroup{}
+
+	go func() {
+		defer close(dispatcherDone)
+		defer close(jobs)
+
+		for epoch := firstEpoch; epoch < endEpoch && ctx.Err() == nil; epoch++ {
+			job := &countingJob{
+				counter: tp.newTransactionsCounter(epoch),
+				done:    make(chan error, 1),
+			}
+
+			select {
+			case jobs <- job:
+			case <-ctx.Done():
+				return
+			}
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				log.Printf("process transactions epoch %d \n", job.counter.epoch)
+				countingStart := time.Now()
+				errCount := processWithWindowRestarts(ctx, job.counter.epoch, job.counter.countEpoch, job.counter.reset)
+				job.duration = time.Since(countingStart)
+				job.done <- errCount
+			}()
+		}
 	}()
 
-	err := tp.elasticHandler.DoScrollRequestAllDocuments(getTransactionsByTimestamp(startTime, endTime), "transactions", tp.processTransactionsResponse)
-	if err != nil {
-		return err
+	return jobs, func() {
+		<-dispatcherDone
+		wg.Wait()
 	}
-
-	tp.stats[tp.epoch].SetInfoAboutDailyAccounts(tp.dailyActiveAccounts)
-	tp.stats[tp.epoch].SetInfoAboutDailyContracts(tp.dailyActiveContracts)
-
-	return nil
 }
 
-func (tp *transactionsProc) processTransactionsResponse(responseBytes []byte) error {
-	txsResponse := data.ScrollTransactionsResponse{}
-	err := json.Unmarshal(responseBytes, &txsResponse)
-	if err != nil {
-		return err
-	}
+// mergeNewAddresses counts the addresses of the epoch that were not seen in any previous epoch and adds them to the
+// known addresses. It returns the complete record of the epoch
+func (tp *transactionsProc) mergeNewAddresses(tc *transactionsCounter) *data.StatisticsEpoch {
+	for address, isSCAddr := range tc.seenAddresses {
+		_, exists := tp.addresses[address]
+		if exists {
+			continue
+		}
 
-	for _, txRes := range txsResponse.Hits.Hits {
-		tp.setMetricForATx(txRes.Tx)
-		tp.checkRelayedTx(txRes.Tx)
+		tp.addresses[address] = struct{}{}
+		tc.stats.DailyNewAddresses++
+		if isSCAddr {
+			tc.stats.DailyNewContractAddresses++
+		}
 	}
 
-	return nil
+	return tc.stats
 }
 
-func (tp *transactionsProc) setMetricForATx(tx dataIndexer.Transaction) {
-	if tx.Sender != fmt.Sprintf("%d", core.MetachainShardId) {
-		tp.dailyActiveAccounts[tx.Sender]++
-	}
+// countNewAddresses returns how many of the provided addresses, and how many of the contracts, were not seen before
+func (tp *transactionsProc) countNewAddresses(seenAddresses map[string]bool) (int, int) {
+	newAddresses, newContracts := 0, 0
+	for address, isSCAddr := range seenAddresses {
+		_, exists := tp.addresses[address]
+		if exists {
+			continue
+		}
 
-	decodedReceiver, _ := tp.pubKeyConverter.Decode(tx.Receiver)
-	isSCAddr := core.IsSmartContractAddress(decodedReceiver)
-	if isSCAddr {
-		tp.dailyActiveContracts[tx.Receiver]++
-		tp.stats[tp.epoch].DailyContractCalls++
+		newAddresses++
+		if isSCAddr {
+			newContracts++
+		}
 	}
 
-	tp.stats[tp.epoch].DailyTransactions++
+	return newAddresses, newContracts
+}
 
-	if tx.Sender == fmt.Sprintf("%d", core.MetachainShardId) {
-		return
-	}
+func (tp *transactionsProc) addComparison(comparison *countingComparison) error {
+	comparison.log()
+	tp.comparisons = append(tp.comparisons, comparison)
 
-	_, exists := tp.addresses[tx.Sender]
-	if !exists {
-		tp.addresses[tx.Sender] = struct{}{}
+	return writeComparisonReport(tp.comparisonReportPath, tp.comparisons)
+}
 
-		tp.stats[tp.epoch].DailyNewAddresses++
+func (tp *transactionsProc) saveCheckpoint() error {
+	state := &transactionsProcessorState{
+		LastEpoch: tp.epoch,
+		Addresses: tp.addresses,
 	}
 
-	_, exists = tp.addresses[tx.Receiver]
-	if !exists {
-		tp.addresses[tx.Receiver] = struct{}{}
+	return tp.stateStorer.SaveCheckpoint(transactionsCheckpointName, tp.epoch, state)
+}
 
-		tp.stats[tp.epoch].DailyNewAddresses++
+// saveBeforeStop saves the state of the epochs merged since the last checkpoint when the run stops before the end
+// epoch. The addresses change only when an epoch is merged, so they are the ones of the last merged epoch
+func (tp *transactionsProc) saveBeforeStop(unsaved bool, errStop error) error {
+	if !unsaved {
+		return errStop
+	}
 
-		if isSCAddr {
-			tp.stats[tp.epoch].DailyNewContractAddresses++
-		}
+	err := tp.saveCheckpoint()
+	if err != nil {
+		log.Printf("cannot save the transactions checkpoint of epoch %d, error %s", tp.epoch, err.Error())
 	}
+
+	return errStop
 }
 
-func (tp *transactionsProc) checkRelayedTx(txx dataIndexer.Transaction) {
-	txData := string(txx.Data)
-	if txData == "" {
-		return
+// loadCheckpoint restores the state needed before processing the start epoch and returns the first epoch
+// that has to be processed. Without a suitable checkpoint, the processing has to start from the first epoch
+func (tp *transactionsProc) loadCheckpoint(startEpoch uint32, resume bool) (uint32, error) {
+	checkpointEpoch, found, err := checkpointToLoad(tp.stateStorer, transactionsCheckpointName, startEpoch, resume)
+	if err != nil {
+		return 0, err
 	}
-	if txx.Status == "fail" {
-		return
+	if !found {
+		return 0, tp.resetState()
 	}
 
-	if !strings.HasPrefix(txData, core.RelayedTransaction) {
-		return
+	err = tp.restoreCheckpoint(checkpointEpoch)
+	if err != nil {
+		return 0, err
 	}
 
-	splitData := strings.Split(txData, "@")
-	if len(splitData) < 2 {
-		return
-	}
+	log.Printf("continue transactions processing from checkpoint of epoch %d \n", checkpointEpoch)
 
-	innerTx := &dataTx.Transaction{}
-	err := json.Unmarshal([]byte(splitData[1]), innerTx)
+	return checkpointEpoch + 1, nil
+}
+
+func (tp *transactionsProc) restoreCheckpoint(checkpointEpoch uint32) error {
+	state := &transactionsProcessorState{}
+	err := tp.stateStorer.LoadCheckpoint(transactionsCheckpointName, checkpointEpoch, state)
 	if err != nil {
-		return
+		return err
 	}
 
-	senderBech32 := tp.pubKeyConverter.Encode(innerTx.SndAddr)
-	receiverBech32 := tp.pubKeyConverter.Encode(innerTx.RcvAddr)
+	tp.addresses = state.Addresses
+	if tp.addresses == nil {
+		tp.addresses = map[string]struct{}{}
+	}
 
-	tp.dailyActiveAccounts[senderBech32]++
+	return nil
+}
 
-	isSCAddr := core.IsSmartContractAddress(innerTx.RcvAddr)
-	if isSCAddr {
-		tp.dailyActiveContracts[receiverBech32]++
-		tp.stats[tp.epoch].DailyContractCalls++
+// resetState sets the state from before the first epoch, the genesis addresses
+func (tp *transactionsProc) resetState() error {
+	addresses, err := genesis.ReadGenesisAddresses(tp.pathToGenesisFiles)
+	if err != nil {
+		return err
 	}
 
-	_, exists := tp.addresses[receiverBech32]
-	if !exists {
-		tp.addresses[receiverBech32] = struct{}{}
+	tp.addresses = addresses
 
-		tp.stats[tp.epoch].DailyNewAddresses++
-
-		if isSCAddr {
-			tp.stats[tp.epoch].DailyNewContractAddresses++
-		}
-	}
+	return nil
 }
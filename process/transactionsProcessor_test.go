@@ -1,21 +1,493 @@
 package process
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"math"
+	"path"
+	"sync"
 	"testing"
 
 	"github.com/ElrondNetwork/elrond-go/config"
 	"github.com/ElrondNetwork/elrond-go/data/state/factory"
+	"github.com/ElrondNetwork/statistics-go/data"
 	"github.com/ElrondNetwork/statistics-go/elasticClient"
+	"github.com/ElrondNetwork/statistics-go/retry"
+	"github.com/ElrondNetwork/statistics-go/state"
 	"github.com/elastic/go-elasticsearch/v7"
+	"github.com/tidwall/gjson"
 )
 
 func TestGetTxs(t *testing.T) {
+	retryPolicy, _ := retry.NewPolicy(1, 0, 0, nil)
 	elsaticC, _ := elasticClient.NewElasticClient(elasticsearch.Config{
 		Addresses: []string{"http://localhost:9200"},
+	}, 0, retryPolicy, 1, elasticClient.PaginationScroll)
+
+	pubKeyConverter, _ := factory.NewPubkeyConverter(config.PubkeyConfig{Type: "bech32", Length: 32})
+
+	stateStorer, _ := state.NewFileStorer(t.TempDir(), 0, 0)
+	epochBoundaries, _ := NewFixedEpochBoundaries(1596117600)
+
+	tp, _ := NewTransactionsProcessor(elsaticC, stateStorer, pubKeyConverter, "../genesis", epochBoundaries, 100, "", 1, 1, TransactionsCountingScan, "", 0, NewDisabledEpochMetrics(), testIndices, false)
+	tp.ProcessAllTxs(context.Background(), 0, 50, false)
+}
+
+// interruptingElasticStub returns no documents and cancels the run on the provided scroll request
+type interruptingElasticStub struct {
+	elasticSearchStub
+	cancel      context.CancelFunc
+	interruptAt int
+	scrolls     int
+}
+
+func (ies *interruptingElasticStub) DoScrollRequestAllDocuments(ctx context.Context, _ *bytes.Buffer, _ string, _ func(responseBytes []byte) error) error {
+	ies.scrolls++
+	if ies.scrolls == ies.interruptAt {
+		ies.cancel()
+		return ctx.Err()
+	}
+
+	return nil
+}
+
+func TestTransactionsProc_InterruptedEpochIsNotSaved(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	pubKeyConverter, _ := factory.NewPubkeyConverter(config.PubkeyConfig{Type: "bech32", Length: 32})
+	stateStorer, _ := state.NewFileStorer(t.TempDir(), 0, 0)
+	epochBoundaries, _ := NewFixedEpochBoundaries(1596117600)
+	handler := &interruptingElasticStub{cancel: cancel, interruptAt: 2}
+
+	tp, _ := NewTransactionsProcessor(handler, stateStorer, pubKeyConverter, "../genesis", epochBoundaries, 100, "", 1, 1, TransactionsCountingScan, "", 0, NewDisabledEpochMetrics(), testIndices, false)
+	records, err := tp.ProcessAllTxs(ctx, 0, 5, false)
+	if err != context.Canceled {
+		t.Fatalf("expected the run to be canceled, got error %v", err)
+	}
+	if len(records) != 1 || records[0].Epoch != 0 {
+		t.Fatalf("expected only the record of epoch 0, got %d records", len(records))
+	}
+
+	lastEpoch, found, err := stateStorer.LastCheckpointEpoch("transactions", math.MaxUint32)
+	if err != nil || !found || lastEpoch != 0 {
+		t.Fatalf("expected the last checkpoint of epoch 0, got %d, found %v, error %v", lastEpoch, found, err)
+	}
+}
+
+func TestTransactionsProc_CheckpointsAreSavedEveryIntervalAndWhenTheRunStops(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	pubKeyConverter, _ := factory.NewPubkeyConverter(config.PubkeyConfig{Type: "bech32", Length: 32})
+	stateStorer, _ := state.NewFileStorer(t.TempDir(), 0, 10)
+	epochBoundaries, _ := NewFixedEpochBoundaries(1596117600)
+	handler := &interruptingElasticStub{cancel: cancel, interruptAt: 4}
+
+	tp, _ := NewTransactionsProcessor(handler, stateStorer, pubKeyConverter, "../genesis", epochBoundaries, 100, "", 1, 1, TransactionsCountingScan, "", 10, NewDisabledEpochMetrics(), testIndices, false)
+	records, err := tp.ProcessAllTxs(ctx, 0, 5, false)
+	if err != context.Canceled {
+		t.Fatalf("expected the run to be canceled, got error %v", err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("expected the records of epochs 0, 1 and 2, got %d records", len(records))
+	}
+
+	// epoch 0 is on the interval, epoch 2 is the last one completed before the interruption
+	lastEpoch, found, err := stateStorer.LastCheckpointEpoch("transactions", math.MaxUint32)
+	if err != nil || !found || lastEpoch != 2 {
+		t.Fatalf("expected the last checkpoint of epoch 2, got %d, found %v, error %v", lastEpoch, found, err)
+	}
+	lastEpoch, found, err = stateStorer.LastCheckpointEpoch("transactions", 1)
+	if err != nil || !found || lastEpoch != 0 {
+		t.Fatalf("expected no checkpoint of epoch 1, got %d, found %v, error %v", lastEpoch, found, err)
+	}
+}
+
+func TestTransactionsProc_ResumeContinuesFromTheCheckpoint(t *testing.T) {
+	pubKeyConverter, _ := factory.NewPubkeyConverter(config.PubkeyConfig{Type: "bech32", Length: 32})
+	stateStorer := newCheckpointStorerStub(t, 2, &transactionsProcessorState{
+		LastEpoch: 2,
+		Addresses: map[string]struct{}{"erd1sender": {}},
 	})
+	epochBoundaries, _ := NewFixedEpochBoundaries(1596117600)
+	handler := &expiringElasticStub{page: []byte(oneTransactionPage)}
+
+	tp, _ := NewTransactionsProcessor(handler, stateStorer, pubKeyConverter, "../genesis", epochBoundaries, 100, "", 1, 1, TransactionsCountingScan, "", 0, NewDisabledEpochMetrics(), testIndices, false)
+	records, err := tp.ProcessAllTxs(context.Background(), 0, 5, true)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if stateStorer.loadedEpoch != 2 || handler.scrolls != 2 {
+		t.Fatalf("expected the checkpoint of epoch 2 and 2 processed epochs, got checkpoint %d, %d scrolls", stateStorer.loadedEpoch, handler.scrolls)
+	}
+	if len(records) != 2 || records[0].Epoch != 3 || records[1].Epoch != 4 {
+		t.Fatalf("expected the records of epochs 3 and 4, got %d records", len(records))
+	}
+
+	// the sender is known from the checkpoint, the receiver is new only in the first processed epoch
+	if records[0].DailyNewAddresses != 1 || records[1].DailyNewAddresses != 0 {
+		t.Fatalf("expected 1 and 0 new addresses, got %d and %d", records[0].DailyNewAddresses, records[1].DailyNewAddresses)
+	}
+	if len(tp.addresses) != 2 {
+		t.Fatalf("expected 2 known addresses, got %d", len(tp.addresses))
+	}
+}
+
+// expiringElasticStub passes the same page on every scroll request and loses the provided scroll requests after
+// the page was handled
+type expiringElasticStub struct {
+	elasticSearchStub
+	page     []byte
+	expireAt map[int]bool
+	scrolls  int
+}
+
+func (ees *expiringElasticStub) DoScrollRequestAllDocuments(_ context.Context, _ *bytes.Buffer, _ string, handlerFunc func(responseBytes []byte) error) error {
+	ees.scrolls++
+	err := handlerFunc(ees.page)
+	if err != nil {
+		return err
+	}
+	if ees.expireAt[ees.scrolls] {
+		return data.ErrScrollExpired
+	}
+
+	return nil
+}
+
+const oneTransactionPage = `{"hits":{"hits":[{"_id":"h1","_source":{"sender":"erd1sender","receiver":"erd1receiver"}}]}}`
+
+func TestTransactionsProc_ExpiredScrollRestartsTheEpoch(t *testing.T) {
+	pubKeyConverter, _ := factory.NewPubkeyConverter(config.PubkeyConfig{Type: "bech32", Length: 32})
+	stateStorer, _ := state.NewFileStorer(t.TempDir(), 0, 0)
+	epochBoundaries, _ := NewFixedEpochBoundaries(1596117600)
+	handler := &expiringElasticStub{page: []byte(oneTransactionPage), expireAt: map[int]bool{1: true, 3: true}}
+
+	tp, _ := NewTransactionsProcessor(handler, stateStorer, pubKeyConverter, "../genesis", epochBoundaries, 100, "", 1, 1, TransactionsCountingScan, "", 0, NewDisabledEpochMetrics(), testIndices, false)
+	records, err := tp.ProcessAllTxs(context.Background(), 0, 2, false)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(records))
+	}
+
+	// the documents read before the scroll expired are not counted twice
+	if records[0].DailyTransactions != 1 || records[0].DailyNewAddresses != 2 {
+		t.Fatalf("epoch 0: expected 1 transaction and 2 new addresses, got %d and %d", records[0].DailyTransactions, records[0].DailyNewAddresses)
+	}
+	if records[1].DailyTransactions != 1 || records[1].DailyNewAddresses != 0 {
+		t.Fatalf("epoch 1: expected 1 transaction and no new address, got %d and %d", records[1].DailyTransactions, records[1].DailyNewAddresses)
+	}
+}
+
+func TestTransactionsProc_ExhaustedRestartsStopTheProcessing(t *testing.T) {
+	pubKeyConverter, _ := factory.NewPubkeyConverter(config.PubkeyConfig{Type: "bech32", Length: 32})
+	stateStorer, _ := state.NewFileStorer(t.TempDir(), 0, 0)
+	epochBoundaries, _ := NewFixedEpochBoundaries(1596117600)
+	expireAt := map[int]bool{}
+	for scroll := 2; scroll <= 2+maxWindowRestarts; scroll++ {
+		expireAt[scroll] = true
+	}
+	handler := &expiringElasticStub{page: []byte(oneTransactionPage), expireAt: expireAt}
+
+	tp, _ := NewTransactionsProcessor(handler, stateStorer, pubKeyConverter, "../genesis", epochBoundaries, 100, "", 1, 1, TransactionsCountingScan, "", 0, NewDisabledEpochMetrics(), testIndices, false)
+	records, err := tp.ProcessAllTxs(context.Background(), 0, 5, false)
+	if !errors.Is(err, data.ErrScrollExpired) {
+		t.Fatalf("expected the scroll expired error, got %v", err)
+	}
+	if len(records) != 1 || records[0].Epoch != 0 {
+		t.Fatalf("expected only the record of epoch 0, got %d records", len(records))
+	}
+
+	lastEpoch, found, err := stateStorer.LastCheckpointEpoch("transactions", math.MaxUint32)
+	if err != nil || !found || lastEpoch != 0 {
+		t.Fatalf("expected the last checkpoint of epoch 0, got %d, found %v, error %v", lastEpoch, found, err)
+	}
+}
+
+// failingElasticStub returns no documents and fails the provided scroll requests
+type failingElasticStub struct {
+	elasticSearchStub
+	failAt  map[int]bool
+	scrolls int
+}
+
+func (fes *failingElasticStub) DoScrollRequestAllDocuments(_ context.Context, _ *bytes.Buffer, _ string, _ func(responseBytes []byte) error) error {
+	fes.scrolls++
+	if fes.failAt[fes.scrolls] {
+		return errors.New("bad request")
+	}
+
+	return nil
+}
+
+func TestTransactionsProc_LenientModeMarksTheFailedEpochs(t *testing.T) {
+	pubKeyConverter, _ := factory.NewPubkeyConverter(config.PubkeyConfig{Type: "bech32", Length: 32})
+	stateStorer, _ := state.NewFileStorer(t.TempDir(), 0, 0)
+	epochBoundaries, _ := NewFixedEpochBoundaries(1596117600)
+	handler := &failingElasticStub{failAt: map[int]bool{2: true}}
+
+	tp, _ := NewTransactionsProcessor(handler, stateStorer, pubKeyConverter, "../genesis", epochBoundaries, 100, "", 1, 1, TransactionsCountingScan, "", 0, NewDisabledEpochMetrics(), testIndices, false)
+	records, err := tp.ProcessAllTxs(context.Background(), 0, 3, false)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("expected 3 records, got %d", len(records))
+	}
+
+	for _, record := range records {
+		expectedStatus := data.EpochStatusOK
+		if record.Epoch == 1 {
+			expectedStatus = data.EpochStatusFailed
+		}
+		if record.Status != expectedStatus {
+			t.Fatalf("epoch %d: expected status %s, got %s", record.Epoch, expectedStatus, record.Status)
+		}
+		if (record.Error != "") != (expectedStatus == data.EpochStatusFailed) {
+			t.Fatalf("epoch %d: unexpected error message %q", record.Epoch, record.Error)
+		}
+	}
+}
+
+func TestTransactionsProc_StrictModeStopsAtTheFirstFailedEpoch(t *testing.T) {
+	pubKeyConverter, _ := factory.NewPubkeyConverter(config.PubkeyConfig{Type: "bech32", Length: 32})
+	stateStorer, _ := state.NewFileStorer(t.TempDir(), 0, 0)
+	epochBoundaries, _ := NewFixedEpochBoundaries(1596117600)
+	handler := &failingElasticStub{failAt: map[int]bool{2: true}}
+
+	tp, _ := NewTransactionsProcessor(handler, stateStorer, pubKeyConverter, "../genesis", epochBoundaries, 100, "", 1, 1, TransactionsCountingScan, "", 0, NewDisabledEpochMetrics(), testIndices, true)
+	records, err := tp.ProcessAllTxs(context.Background(), 0, 3, false)
+	if err == nil {
+		t.Fatal("expected the error of epoch 1")
+	}
+	if len(records) != 1 || records[0].Epoch != 0 {
+		t.Fatalf("expected only the record of epoch 0, got %d records", len(records))
+	}
+
+	lastEpoch, found, err := stateStorer.LastCheckpointEpoch("transactions", math.MaxUint32)
+	if err != nil || !found || lastEpoch != 0 {
+		t.Fatalf("expected the last checkpoint of epoch 0, got %d, found %v, error %v", lastEpoch, found, err)
+	}
+}
+
+// concurrentElasticStub passes the same page on every scroll request and can be used by more workers at once
+type concurrentElasticStub struct {
+	elasticSearchStub
+	page       []byte
+	mutScrolls sync.Mutex
+	scrolls    int
+}
+
+func (ces *concurrentElasticStub) DoScrollRequestAllDocuments(_ context.Context, _ *bytes.Buffer, _ string, handlerFunc func(responseBytes []byte) error) error {
+	ces.mutScrolls.Lock()
+	ces.scrolls++
+	ces.mutScrolls.Unlock()
+
+	return handlerFunc(ces.page)
+}
+
+func TestTransactionsProc_WorkersMergeTheEpochsInOrder(t *testing.T) {
+	pubKeyConverter, _ := factory.NewPubkeyConverter(config.PubkeyConfig{Type: "bech32", Length: 32})
+	stateStorer, _ := state.NewFileStorer(t.TempDir(), 0, 0)
+	epochBoundaries, _ := NewFixedEpochBoundaries(1596117600)
+	handler := &concurrentElasticStub{page: []byte(oneTransactionPage)}
+
+	tp, _ := NewTransactionsProcessor(handler, stateStorer, pubKeyConverter, "../genesis", epochBoundaries, 100, "", 4, 1, TransactionsCountingScan, "", 0, NewDisabledEpochMetrics(), testIndices, false)
+	records, err := tp.ProcessAllTxs(context.Background(), 0, 10, false)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if len(records) != 10 || handler.scrolls != 10 {
+		t.Fatalf("expected 10 records from 10 scrolls, got %d records from %d scrolls", len(records), handler.scrolls)
+	}
+
+	for idx, record := range records {
+		expectedNewAddresses := 0
+		if idx == 0 {
+			expectedNewAddresses = 2
+		}
+		if record.Epoch != uint32(idx) || record.DailyTransactions != 1 || record.DailyNewAddresses != expectedNewAddresses {
+			t.Fatalf("unexpected record %d: epoch %d, %d transactions, %d new addresses", idx, record.Epoch, record.DailyTransactions, record.DailyNewAddresses)
+		}
+	}
+}
+
+func TestNewTransactionsProcessor_InvalidWorkers(t *testing.T) {
+	pubKeyConverter, _ := factory.NewPubkeyConverter(config.PubkeyConfig{Type: "bech32", Length: 32})
+	stateStorer, _ := state.NewFileStorer(t.TempDir(), 0, 0)
+	epochBoundaries, _ := NewFixedEpochBoundaries(1596117600)
+
+	_, err := NewTransactionsProcessor(&concurrentElasticStub{}, stateStorer, pubKeyConverter, "../genesis", epochBoundaries, 100, "", 0, 1, TransactionsCountingScan, "", 0, NewDisabledEpochMetrics(), testIndices, false)
+	if err == nil {
+		t.Fatal("expected error for 0 workers")
+	}
+}
+
+const contractAddress = "erd1qqqqqqqqqqqqqpgqpg9scrgwpugpzysnzs23v9ccrydpk8qarc0sltk5pz"
+
+// the same two transactions as documents and as aggregations: an account calls a contract and the metachain sends
+// a transaction to the account
+const (
+	contractCallPage = `{"hits":{"hits":[
+		{"_id":"h1","_source":{"sender":"erd1sender","receiver":"` + contractAddress + `"}},
+		{"_id":"h2","_source":{"sender":"4294967295","receiver":"erd1sender"}}]}}`
+	histogramAggregation = `{"transactions":{"buckets":[{"doc_count":1},{"doc_count":0},{"doc_count":1}]}}`
+	sendersAggregation   = `{"addresses":{"after_key":{"address":"erd1sender"},"buckets":[
+		{"key":{"address":"4294967295"},"doc_count":1,"notFromMetachain":{"doc_count":0}},
+		{"key":{"address":"erd1sender"},"doc_count":1,"notFromMetachain":{"doc_count":1}}]}}`
+	receiversAggregation = `{"addresses":{"after_key":{"address":"erd1sender"},"buckets":[
+		{"key":{"address":"` + contractAddress + `"},"doc_count":1,"notFromMetachain":{"doc_count":1}},
+		{"key":{"address":"erd1sender"},"doc_count":1,"notFromMetachain":{"doc_count":0}}]}}`
+)
+
+// aggregationElasticStub serves the same transactions as documents and as aggregations
+type aggregationElasticStub struct {
+	elasticSearchStub
+	mutRequests  sync.Mutex
+	scrolls      int
+	aggregations int
+}
+
+func (aes *aggregationElasticStub) DoScrollRequestAllDocuments(_ context.Context, _ *bytes.Buffer, _ string, handlerFunc func(responseBytes []byte) error) error {
+	aes.mutRequests.Lock()
+	aes.scrolls++
+	aes.mutRequests.Unlock()
 
+	return handlerFunc([]byte(contractCallPage))
+}
+
+func (aes *aggregationElasticStub) DoAggregationRequest(_ context.Context, query *bytes.Buffer, _ string) ([]byte, error) {
+	aes.mutRequests.Lock()
+	aes.aggregations++
+	aes.mutRequests.Unlock()
+
+	switch gjson.Get(query.String(), "aggs.addresses.composite.sources.0.address.terms.field").String() {
+	case senderKeywordField:
+		return []byte(sendersAggregation), nil
+	case receiverKeywordField:
+		return []byte(receiversAggregation), nil
+	default:
+		return []byte(histogramAggregation), nil
+	}
+}
+
+func processWithCountingMode(t *testing.T, handler ElasticHandler, countingMode string, comparisonReportPath string) []*data.StatisticsEpoch {
+	pubKeyConverter, _ := factory.NewPubkeyConverter(config.PubkeyConfig{Type: "bech32", Length: 32})
+	stateStorer, _ := state.NewFileStorer(t.TempDir(), 0, 0)
+	epochBoundaries, _ := NewFixedEpochBoundaries(1596117600)
+
+	tp, err := NewTransactionsProcessor(handler, stateStorer, pubKeyConverter, "../genesis", epochBoundaries, 100, "", 2, 1, countingMode, comparisonReportPath, 0, NewDisabledEpochMetrics(), testIndices, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := tp.ProcessAllTxs(context.Background(), 0, 3, false)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	return records
+}
+
+func TestTransactionsProc_AggregationCountsLikeTheScan(t *testing.T) {
+	handler := &aggregationElasticStub{}
+	scanRecords := processWithCountingMode(t, handler, TransactionsCountingScan, "")
+	aggregationRecords := processWithCountingMode(t, handler, TransactionsCountingAggregation, "")
+
+	if handler.scrolls != 3 || handler.aggregations != 9 {
+		t.Fatalf("expected 3 scrolls and 9 aggregations, got %d scrolls and %d aggregations", handler.scrolls, handler.aggregations)
+	}
+
+	scanBytes, _ := json.Marshal(scanRecords)
+	aggregationBytes, _ := json.Marshal(aggregationRecords)
+	if string(scanBytes) != string(aggregationBytes) {
+		t.Fatalf("the counting modes differ:\nscan        %s\naggregation %s", scanBytes, aggregationBytes)
+	}
+
+	first := aggregationRecords[0]
+	if first.DailyTransactions != 2 || first.DailyContractCalls != 1 || first.DailyActiveAccounts != 1 ||
+		first.DailyActiveContractAccounts != 1 || first.DailyNewAddresses != 2 || first.DailyNewContractAddresses != 1 {
+		t.Fatalf("unexpected record %+v", first)
+	}
+	if aggregationRecords[1].DailyNewAddresses != 0 {
+		t.Fatalf("expected no new addresses in the second epoch, got %d", aggregationRecords[1].DailyNewAddresses)
+	}
+}
+
+func TestTransactionsProc_CompareWritesTheReport(t *testing.T) {
+	reportPath := path.Join(t.TempDir(), "comparison.json")
+	records := processWithCountingMode(t, &aggregationElasticStub{}, TransactionsCountingCompare, reportPath)
+	if len(records) != 3 || records[0].DailyTransactions != 2 {
+		t.Fatalf("expected the scan records of 3 epochs, got %d records", len(records))
+	}
+
+	reportBytes, err := ioutil.ReadFile(reportPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	comparisons := make([]*countingComparison, 0)
+	err = json.Unmarshal(reportBytes, &comparisons)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(comparisons) != 3 {
+		t.Fatalf("expected the comparisons of 3 epochs, got %d", len(comparisons))
+	}
+	for idx, comparison := range comparisons {
+		if comparison.Epoch != uint32(idx) || len(comparison.Metrics) != 6 {
+			t.Fatalf("unexpected comparison %+v", comparison)
+		}
+		for _, metric := range comparison.Metrics {
+			if metric.Difference != 0 {
+				t.Fatalf("unexpected difference in epoch %d: %+v", comparison.Epoch, metric)
+			}
+		}
+	}
+}
+
+func TestTransactionsProc_CompareSkipsTheFailedEpochs(t *testing.T) {
 	pubKeyConverter, _ := factory.NewPubkeyConverter(config.PubkeyConfig{Type: "bech32", Length: 32})
+	stateStorer, _ := state.NewFileStorer(t.TempDir(), 0, 0)
+	epochBoundaries, _ := NewFixedEpochBoundaries(1596117600)
+	reportPath := path.Join(t.TempDir(), "comparison.json")
+	// the active addresses cannot be written in a missing folder, so every epoch fails after it was counted
+	missingFolder := path.Join(t.TempDir(), "missing")
+
+	tp, _ := NewTransactionsProcessor(&aggregationElasticStub{}, stateStorer, pubKeyConverter, "../genesis", epochBoundaries, 100, missingFolder, 1, 1, TransactionsCountingCompare, reportPath, 0, NewDisabledEpochMetrics(), testIndices, false)
+	records, err := tp.ProcessAllTxs(context.Background(), 0, 2, false)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	for _, record := range records {
+		if record.Status != data.EpochStatusFailed || record.Error == "" {
+			t.Fatalf("expected epoch %d with the failed status, got %s", record.Epoch, record.Status)
+		}
+	}
+	if len(tp.comparisons) != 0 {
+		t.Fatalf("expected no comparison of the failed epochs, got %d", len(tp.comparisons))
+	}
+}
+
+func TestNewTransactionsProcessor_InvalidCountingMode(t *testing.T) {
+	pubKeyConverter, _ := factory.NewPubkeyConverter(config.PubkeyConfig{Type: "bech32", Length: 32})
+	stateStorer, _ := state.NewFileStorer(t.TempDir(), 0, 0)
+	epochBoundaries, _ := NewFixedEpochBoundaries(1596117600)
+
+	_, err := NewTransactionsProcessor(&aggregationElasticStub{}, stateStorer, pubKeyConverter, "../genesis", epochBoundaries, 100, "", 1, 1, "sample", "", 0, NewDisabledEpochMetrics(), testIndices, false)
+	if err == nil {
+		t.Fatal("expected error for an unknown counting mode")
+	}
 
-	tp, _ := NewTransactionsProcessor(elsaticC, pubKeyConverter, "../genesis", 1596117600)
-	tp.ProcessAllTxs(50)
+	_, err = NewTransactionsProcessor(&aggregationElasticStub{}, stateStorer, pubKeyConverter, "../genesis", epochBoundaries, 100, "", 1, 1, TransactionsCountingCompare, "", 0, NewDisabledEpochMetrics(), testIndices, false)
+	if err == nil {
+		t.Fatal("expected error for the compare mode without a report file")
+	}
 }
@@ -0,0 +1,102 @@
+package process
+
+import (
+	"math/big"
+	"sort"
+
+	"github.com/ElrondNetwork/statistics-go/data"
+)
+
+// computeWealthConcentration returns the distribution metrics of the provided non-zero balances
+func computeWealthConcentration(balances []*big.Int) *data.WealthConcentration {
+	sorted := make([]*big.Int, len(balances))
+	copy(sorted, balances)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Cmp(sorted[j]) < 0
+	})
+
+	total := big.NewInt(0)
+	for _, balance := range sorted {
+		total.Add(total, balance)
+	}
+
+	return &data.WealthConcentration{
+		TotalBalance:        total.String(),
+		MedianBalance:       percentile(sorted, 50, 100).String(),
+		P90Balance:          percentile(sorted, 90, 100).String(),
+		P99Balance:          percentile(sorted, 99, 100).String(),
+		P999Balance:         percentile(sorted, 999, 1000).String(),
+		GiniCoefficient:     giniCoefficient(sorted, total),
+		NakamotoCoefficient: nakamotoCoefficient(sorted, total),
+		Top10Share:          topShare(sorted, total, 10),
+		Top100Share:         topShare(sorted, total, 100),
+		Top1000Share:        topShare(sorted, total, 1000),
+	}
+}
+
+// percentile returns the nearest rank percentile numerator/denominator of the ascending sorted balances
+func percentile(sorted []*big.Int, numerator int, denominator int) *big.Int {
+	if len(sorted) == 0 {
+		return big.NewInt(0)
+	}
+
+	rank := (numerator*len(sorted) + denominator - 1) / denominator
+	if rank < 1 {
+		rank = 1
+	}
+
+	return sorted[rank-1]
+}
+
+// giniCoefficient computes sum((2i - n - 1) * x_i) / (n * sum(x_i)) for the ascending sorted balances, i = 1..n
+func giniCoefficient(sorted []*big.Int, total *big.Int) float64 {
+	n := int64(len(sorted))
+	if n == 0 || total.Sign() == 0 {
+		return 0
+	}
+
+	numerator := big.NewInt(0)
+	for idx, balance := range sorted {
+		weight := big.NewInt(2*int64(idx+1) - n - 1)
+		numerator.Add(numerator, weight.Mul(weight, balance))
+	}
+
+	denominator := big.NewInt(0).Mul(big.NewInt(n), total)
+	gini, _ := big.NewRat(0, 1).SetFrac(numerator, denominator).Float64()
+
+	return gini
+}
+
+// nakamotoCoefficient returns the minimum number of addresses that together hold more than half of the total balance
+func nakamotoCoefficient(sorted []*big.Int, total *big.Int) int {
+	if total.Sign() == 0 {
+		return 0
+	}
+
+	half := big.NewInt(0).Div(total, big.NewInt(2))
+	held := big.NewInt(0)
+	for idx := len(sorted) - 1; idx >= 0; idx-- {
+		held.Add(held, sorted[idx])
+		if held.Cmp(half) > 0 {
+			return len(sorted) - idx
+		}
+	}
+
+	return len(sorted)
+}
+
+// topShare returns the fraction of the total balance held by the richest count addresses
+func topShare(sorted []*big.Int, total *big.Int, count int) float64 {
+	if total.Sign() == 0 {
+		return 0
+	}
+
+	held := big.NewInt(0)
+	for idx := len(sorted) - 1; idx >= 0 && idx >= len(sorted)-count; idx-- {
+		held.Add(held, sorted[idx])
+	}
+
+	share, _ := big.NewRat(0, 1).SetFrac(held, total).Float64()
+
+	return share
+}
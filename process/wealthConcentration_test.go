@@ -0,0 +1,52 @@
+package process
+
+import (
+	"math"
+	"math/big"
+	"testing"
+)
+
+func TestComputeWealthConcentration(t *testing.T) {
+	balances := make([]*big.Int, 0)
+	for value := int64(10); value >= 1; value-- {
+		balances = append(balances, big.NewInt(value))
+	}
+
+	concentration := computeWealthConcentration(balances)
+
+	if concentration.TotalBalance != "55" {
+		t.Fatalf("unexpected total balance %s", concentration.TotalBalance)
+	}
+	if concentration.MedianBalance != "5" || concentration.P90Balance != "9" || concentration.P99Balance != "10" {
+		t.Fatalf("unexpected percentiles %s %s %s", concentration.MedianBalance, concentration.P90Balance, concentration.P99Balance)
+	}
+	// 10 + 9 + 8 = 27 is not more than half of 55, 10 + 9 + 8 + 7 = 34 is
+	if concentration.NakamotoCoefficient != 4 {
+		t.Fatalf("unexpected nakamoto coefficient %d", concentration.NakamotoCoefficient)
+	}
+	if concentration.Top10Share != 1 || concentration.Top1000Share != 1 {
+		t.Fatalf("unexpected top shares %f %f", concentration.Top10Share, concentration.Top1000Share)
+	}
+	if math.Abs(concentration.GiniCoefficient-0.3) > 1e-9 {
+		t.Fatalf("unexpected gini coefficient %f", concentration.GiniCoefficient)
+	}
+}
+
+func TestComputeWealthConcentration_EqualBalances(t *testing.T) {
+	balances := []*big.Int{big.NewInt(7), big.NewInt(7), big.NewInt(7), big.NewInt(7)}
+
+	concentration := computeWealthConcentration(balances)
+	if concentration.GiniCoefficient != 0 {
+		t.Fatalf("expected gini coefficient 0, got %f", concentration.GiniCoefficient)
+	}
+	if concentration.NakamotoCoefficient != 3 {
+		t.Fatalf("expected nakamoto coefficient 3, got %d", concentration.NakamotoCoefficient)
+	}
+}
+
+func TestComputeWealthConcentration_NoBalances(t *testing.T) {
+	concentration := computeWealthConcentration([]*big.Int{})
+	if concentration.TotalBalance != "0" || concentration.MedianBalance != "0" || concentration.GiniCoefficient != 0 {
+		t.Fatalf("unexpected concentration %+v", concentration)
+	}
+}
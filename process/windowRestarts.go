@@ -0,0 +1,42 @@
+package process
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/ElrondNetwork/statistics-go/data"
+)
+
+// maxWindowRestarts bounds how many times the documents of an epoch are read again after their scroll expired
+const maxWindowRestarts = 3
+
+// processWithWindowRestarts processes an epoch and, when its scroll expires midway, restores the state from before
+// the epoch and processes the whole time window again
+func processWithWindowRestarts(
+	ctx context.Context,
+	epoch uint32,
+	processEpoch func(ctx context.Context) error,
+	restoreStateBefore func(epoch uint32) error,
+) error {
+	err := processEpoch(ctx)
+	for restart := 1; errors.Is(err, data.ErrScrollExpired) && restart <= maxWindowRestarts; restart++ {
+		log.Printf("scroll expired while processing epoch %d, restart the time window (%d/%d) \n", epoch, restart, maxWindowRestarts)
+
+		err = restoreStateBefore(epoch)
+		if err != nil {
+			return fmt.Errorf("%w before epoch %d: %v", data.ErrStateRestore, epoch, err)
+		}
+
+		err = processEpoch(ctx)
+	}
+
+	return err
+}
+
+// isHardError returns true for the errors after which the processing cannot continue with the next epoch, since the
+// documents of the epoch could not be read even after retrying or the state from before the epoch was lost
+func isHardError(err error) bool {
+	return errors.Is(err, data.ErrRetriesExhausted) || errors.Is(err, data.ErrScrollExpired) || errors.Is(err, data.ErrStateRestore)
+}
@@ -0,0 +1,24 @@
+package process
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/ElrondNetwork/statistics-go/data"
+)
+
+func TestProcessWithWindowRestarts_FailedRestoreIsHardError(t *testing.T) {
+	processEpoch := func(_ context.Context) error {
+		return fmt.Errorf("%w: page lost", data.ErrScrollExpired)
+	}
+	restoreStateBefore := func(_ uint32) error {
+		return errors.New("no checkpoint")
+	}
+
+	err := processWithWindowRestarts(context.Background(), 5, processEpoch, restoreStateBefore)
+	if !errors.Is(err, data.ErrStateRestore) || !isHardError(err) {
+		t.Fatalf("expected a hard state restore error, got %v", err)
+	}
+}
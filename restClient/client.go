@@ -2,39 +2,62 @@ package restClient
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"time"
 
 	logger "github.com/ElrondNetwork/elrond-go-logger"
 	"github.com/ElrondNetwork/statistics-go/data"
+	"github.com/ElrondNetwork/statistics-go/retry"
 )
 
 var log = logger.GetOrCreate("restClient")
 
 type restClient struct {
-	httpClient *http.Client
-	url        string
+	httpClient   *http.Client
+	url          string
+	retryHandler RetryHandler
 }
 
-// NewRestClient will create a new instance of restClient
-func NewRestClient(url string) (*restClient, error) {
-	c := http.DefaultClient
+// NewRestClient will create a new instance of restClient. Every request is bounded by the provided timeout,
+// 0 means no timeout, and the failed requests are retried by the provided retry handler
+func NewRestClient(url string, requestTimeout time.Duration, retryHandler RetryHandler) (*restClient, error) {
+	if retryHandler == nil {
+		return nil, errors.New("nil retry handler")
+	}
+
+	c := &http.Client{
+		Timeout: requestTimeout,
+	}
 
 	return &restClient{
-		httpClient: c,
-		url:        url,
+		httpClient:   c,
+		url:          url,
+		retryHandler: retryHandler,
 	}, nil
 }
 
 // CallGetRestEndPoint calls an external end point (sends a get request)
 func (rc *restClient) CallGetRestEndPoint(
+	ctx context.Context,
 	path string,
 	value interface{},
 ) error {
-	req, err := http.NewRequest("GET", rc.url+path, nil)
+	return rc.retryHandler.Do(ctx, "GET "+path, func() error {
+		return rc.callGetRestEndPoint(ctx, path, value)
+	})
+}
+
+func (rc *restClient) callGetRestEndPoint(
+	ctx context.Context,
+	path string,
+	value interface{},
+) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", rc.url+path, nil)
 	if err != nil {
 		return err
 	}
@@ -55,14 +78,9 @@ func (rc *restClient) CallGetRestEndPoint(
 		}
 	}()
 
-	err = json.NewDecoder(resp.Body).Decode(value)
-	if err != nil {
-		return err
-	}
-
 	responseStatusCode := resp.StatusCode
 	if responseStatusCode == http.StatusOK {
-		return nil
+		return json.NewDecoder(resp.Body).Decode(value)
 	}
 
 	// status response not ok, return the error
@@ -71,11 +89,26 @@ func (rc *restClient) CallGetRestEndPoint(
 		return err
 	}
 
-	return errors.New(string(responseBytes))
+	return &retry.StatusError{
+		StatusCode: responseStatusCode,
+		Message:    string(responseBytes),
+	}
 }
 
-// CallGetRestEndPoint calls an external end point (sends a post request)
+// CallPostRestEndPoint calls an external end point (sends a post request)
 func (rc *restClient) CallPostRestEndPoint(
+	ctx context.Context,
+	path string,
+	dataR interface{},
+	response interface{},
+) error {
+	return rc.retryHandler.Do(ctx, "POST "+path, func() error {
+		return rc.callPostRestEndPoint(ctx, path, dataR, response)
+	})
+}
+
+func (rc *restClient) callPostRestEndPoint(
+	ctx context.Context,
 	path string,
 	dataR interface{},
 	response interface{},
@@ -85,7 +118,7 @@ func (rc *restClient) CallPostRestEndPoint(
 		return err
 	}
 
-	req, err := http.NewRequest("POST", rc.url+path, bytes.NewReader(buff))
+	req, err := http.NewRequestWithContext(ctx, "POST", rc.url+path, bytes.NewReader(buff))
 	if err != nil {
 		return err
 	}
@@ -119,8 +152,14 @@ func (rc *restClient) CallPostRestEndPoint(
 	genericApiResponse := data.GenericAPIResponse{}
 	err = json.Unmarshal(responseBytes, &genericApiResponse)
 	if err != nil {
-		return fmt.Errorf("error unmarshaling response: %w", err)
+		return &retry.StatusError{
+			StatusCode: responseStatusCode,
+			Message:    fmt.Sprintf("error unmarshaling response: %s", err.Error()),
+		}
 	}
 
-	return errors.New(genericApiResponse.Error)
+	return &retry.StatusError{
+		StatusCode: responseStatusCode,
+		Message:    genericApiResponse.Error,
+	}
 }
@@ -0,0 +1,8 @@
+package restClient
+
+import "context"
+
+// RetryHandler calls a request again while it fails with a transient error
+type RetryHandler interface {
+	Do(ctx context.Context, operation string, action func() error) error
+}
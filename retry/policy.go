@@ -0,0 +1,126 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/ElrondNetwork/statistics-go/data"
+)
+
+// StatusError is returned by the clients for the responses with an unsuccessful status code
+type StatusError struct {
+	StatusCode int
+	Message    string
+}
+
+// Error returns the status code and the message of the response
+func (se *StatusError) Error() string {
+	return fmt.Sprintf("status code %d: %s", se.StatusCode, se.Message)
+}
+
+type policy struct {
+	maxAttempts       int
+	initialBackoff    time.Duration
+	maxBackoff        time.Duration
+	retryableStatuses map[int]struct{}
+	mutRandom         sync.Mutex
+	random            *rand.Rand
+}
+
+// NewPolicy will create a new instance of policy that retries a failed request up to maxAttempts times in total,
+// waiting an exponential backoff with jitter between attempts. The responses with one of the retryable status codes
+// and the transport errors are retried, the other responses are returned as they are
+func NewPolicy(maxAttempts int, initialBackoff time.Duration, maxBackoff time.Duration, retryableStatusCodes []int) (*policy, error) {
+	if maxAttempts < 1 {
+		return nil, fmt.Errorf("invalid number of attempts: %d", maxAttempts)
+	}
+	if initialBackoff < 0 || maxBackoff < initialBackoff {
+		return nil, fmt.Errorf("invalid backoff interval %s-%s", initialBackoff, maxBackoff)
+	}
+
+	retryableStatuses := make(map[int]struct{}, len(retryableStatusCodes))
+	for _, statusCode := range retryableStatusCodes {
+		retryableStatuses[statusCode] = struct{}{}
+	}
+
+	return &policy{
+		maxAttempts:       maxAttempts,
+		initialBackoff:    initialBackoff,
+		maxBackoff:        maxBackoff,
+		retryableStatuses: retryableStatuses,
+		random:            rand.New(rand.NewSource(time.Now().UnixNano())),
+	}, nil
+}
+
+// Do will call the provided action until it succeeds, fails with an error that is not retryable or the attempts
+// are exhausted. Exhausted attempts are reported with data.ErrRetriesExhausted
+func (p *policy) Do(ctx context.Context, operation string, action func() error) error {
+	var err error
+	for attempt := 1; attempt <= p.maxAttempts; attempt++ {
+		err = action()
+		if err == nil || !p.isRetryable(ctx, err) {
+			return err
+		}
+		if attempt == p.maxAttempts {
+			break
+		}
+
+		backoff := p.backoff(attempt)
+		log.Printf("retrying %s after attempt %d in %s, error %s", operation, attempt, backoff, err.Error())
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+
+	log.Printf("%s failed after %d attempts, error %s", operation, p.maxAttempts, err.Error())
+
+	return fmt.Errorf("%w: %s failed after %d attempts: %v", data.ErrRetriesExhausted, operation, p.maxAttempts, err)
+}
+
+func (p *policy) isRetryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil || errors.Is(err, data.ErrScrollExpired) {
+		return false
+	}
+
+	statusErr := &StatusError{}
+	if errors.As(err, &statusErr) {
+		_, ok := p.retryableStatuses[statusErr.StatusCode]
+		return ok
+	}
+
+	// the requests that did not get a response failed in transport
+	return true
+}
+
+// backoff doubles the initial backoff with every attempt, up to the max backoff, and picks a random duration
+// between its half and its full value so the clients do not retry in lockstep
+func (p *policy) backoff(attempt int) time.Duration {
+	backoff := p.initialBackoff
+	for i := 1; i < attempt && backoff < p.maxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > p.maxBackoff {
+		backoff = p.maxBackoff
+	}
+
+	half := int64(backoff / 2)
+	if half == 0 {
+		return backoff
+	}
+
+	p.mutRandom.Lock()
+	jitter := p.random.Int63n(half + 1)
+	p.mutRandom.Unlock()
+
+	return time.Duration(half + jitter)
+}
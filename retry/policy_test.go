@@ -0,0 +1,111 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ElrondNetwork/statistics-go/data"
+)
+
+var retryableStatusCodes = []int{http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout}
+
+func TestNewPolicy_InvalidSettings(t *testing.T) {
+	_, err := NewPolicy(0, 0, 0, nil)
+	if err == nil {
+		t.Fatal("expected error for 0 attempts")
+	}
+
+	_, err = NewPolicy(3, time.Second, time.Millisecond, nil)
+	if err == nil {
+		t.Fatal("expected error for a max backoff lower than the initial backoff")
+	}
+}
+
+func TestPolicy_RetriesRetryableStatuses(t *testing.T) {
+	p, _ := NewPolicy(3, time.Millisecond, time.Millisecond, retryableStatusCodes)
+
+	calls := 0
+	err := p.Do(context.Background(), "test", func() error {
+		calls++
+		if calls < 3 {
+			return &StatusError{StatusCode: http.StatusServiceUnavailable}
+		}
+		return nil
+	})
+	if err != nil || calls != 3 {
+		t.Fatalf("expected success after 3 calls, got %d calls, error %v", calls, err)
+	}
+}
+
+func TestPolicy_DoesNotRetryOtherErrorResponses(t *testing.T) {
+	p, _ := NewPolicy(3, time.Millisecond, time.Millisecond, retryableStatusCodes)
+
+	calls := 0
+	err := p.Do(context.Background(), "test", func() error {
+		calls++
+		return &StatusError{StatusCode: http.StatusBadRequest}
+	})
+	if calls != 1 || errors.Is(err, data.ErrRetriesExhausted) {
+		t.Fatalf("expected the bad request to be returned after 1 call, got %d calls, error %v", calls, err)
+	}
+
+	calls = 0
+	err = p.Do(context.Background(), "test", func() error {
+		calls++
+		return data.ErrScrollExpired
+	})
+	if calls != 1 || !errors.Is(err, data.ErrScrollExpired) {
+		t.Fatalf("expected the expired scroll to be returned after 1 call, got %d calls, error %v", calls, err)
+	}
+}
+
+func TestPolicy_ExhaustedAttempts(t *testing.T) {
+	p, _ := NewPolicy(4, time.Millisecond, time.Millisecond, retryableStatusCodes)
+
+	calls := 0
+	errTransport := errors.New("connection refused")
+	err := p.Do(context.Background(), "test", func() error {
+		calls++
+		return errTransport
+	})
+	if calls != 4 {
+		t.Fatalf("expected 4 calls, got %d", calls)
+	}
+	if !errors.Is(err, data.ErrRetriesExhausted) {
+		t.Fatalf("expected the retries exhausted error, got %v", err)
+	}
+}
+
+func TestPolicy_StopsWhenTheContextIsCanceled(t *testing.T) {
+	p, _ := NewPolicy(5, time.Hour, time.Hour, retryableStatusCodes)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	calls := 0
+	err := p.Do(ctx, "test", func() error {
+		calls++
+		cancel()
+		return errors.New("connection reset")
+	})
+	if calls != 1 || errors.Is(err, data.ErrRetriesExhausted) {
+		t.Fatalf("expected the error of the canceled call to be returned after 1 call, got %d calls, error %v", calls, err)
+	}
+}
+
+func TestPolicy_BackoffIsBounded(t *testing.T) {
+	p, _ := NewPolicy(10, 100*time.Millisecond, time.Second, nil)
+
+	for attempt := 1; attempt < 10; attempt++ {
+		expected := 100 * time.Millisecond << uint(attempt-1)
+		if expected > time.Second {
+			expected = time.Second
+		}
+
+		backoff := p.backoff(attempt)
+		if backoff < expected/2 || backoff > expected {
+			t.Fatalf("attempt %d: backoff %s out of [%s, %s]", attempt, backoff, expected/2, expected)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	// registers the pure go sqlite driver
+	_ "modernc.org/sqlite"
+)
+
+const (
+	driverName       = "sqlite"
+	busyTimeoutMilli = 5000
+)
+
+// OpenDatabase will open, and create if missing, the sqlite database from the provided path
+func OpenDatabase(databasePath string) (*sql.DB, error) {
+	if databasePath == "" {
+		return nil, fmt.Errorf("empty database path")
+	}
+
+	err := os.MkdirAll(filepath.Dir(databasePath), os.ModePerm)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create database folder %w", err)
+	}
+
+	db, err := sql.Open(driverName, databasePath)
+	if err != nil {
+		return nil, err
+	}
+
+	// the sink and the storers share the handle, a single connection keeps the pragmas below applied and
+	// serializes their writes
+	db.SetMaxOpenConns(1)
+	pragmas := []string{
+		fmt.Sprintf("PRAGMA busy_timeout = %d", busyTimeoutMilli),
+		"PRAGMA journal_mode = WAL",
+	}
+	for _, pragma := range pragmas {
+		_, err = db.Exec(pragma)
+		if err != nil {
+			_ = db.Close()
+			return nil, fmt.Errorf("cannot configure database %s: %w", databasePath, err)
+		}
+	}
+
+	return db, nil
+}
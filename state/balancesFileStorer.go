@@ -0,0 +1,73 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+)
+
+type balancesFileStorer struct {
+	folder string
+}
+
+// NewBalancesFileStorer will create a new instance of balancesFileStorer that keeps the staked balances of every
+// epoch as a json file in the provided folder
+func NewBalancesFileStorer(folder string) (*balancesFileStorer, error) {
+	if folder == "" {
+		return nil, fmt.Errorf("empty stake balances folder")
+	}
+
+	err := os.MkdirAll(folder, os.ModePerm)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create stake balances folder %w", err)
+	}
+
+	return &balancesFileStorer{
+		folder: folder,
+	}, nil
+}
+
+// SaveStakeBalances will write the staked balances of the given epoch
+func (bfs *balancesFileStorer) SaveStakeBalances(epoch uint32, balances map[string]string) error {
+	bytes, err := json.MarshalIndent(balances, "", " ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(bfs.balancesFile(epoch), bytes, 0644)
+}
+
+// LoadStakeBalances will read the staked balances of the given epoch
+func (bfs *balancesFileStorer) LoadStakeBalances(epoch uint32) (map[string]string, error) {
+	bytes, err := ioutil.ReadFile(bfs.balancesFile(epoch))
+	if err != nil {
+		return nil, err
+	}
+
+	balances := map[string]string{}
+	err = json.Unmarshal(bytes, &balances)
+	if err != nil {
+		return nil, err
+	}
+
+	return balances, nil
+}
+
+// HasStakeBalances returns true if the staked balances of the given epoch were written
+func (bfs *balancesFileStorer) HasStakeBalances(epoch uint32) (bool, error) {
+	_, err := os.Stat(bfs.balancesFile(epoch))
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (bfs *balancesFileStorer) balancesFile(epoch uint32) string {
+	return path.Join(bfs.folder, fmt.Sprintf("epoch%d.json", epoch))
+}
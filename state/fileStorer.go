@@ -0,0 +1,176 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	checkpointPrefix    = "epoch"
+	checkpointExtension = ".json"
+)
+
+type fileStorer struct {
+	folder              string
+	checkpointsToKeep   int
+	checkpointsInterval int
+}
+
+// NewFileStorer will create a new instance of fileStorer that keeps every checkpoint as a json file
+// in a sub folder of the provided folder. Besides the last checkpointsToKeep checkpoints, the ones of every
+// checkpointsInterval epochs are kept, 0 disables them
+func NewFileStorer(folder string, checkpointsToKeep int, checkpointsInterval int) (*fileStorer, error) {
+	if folder == "" {
+		return nil, fmt.Errorf("empty state folder")
+	}
+	if checkpointsToKeep < 0 {
+		return nil, fmt.Errorf("invalid number of checkpoints to keep: %d", checkpointsToKeep)
+	}
+	if checkpointsInterval < 0 {
+		return nil, fmt.Errorf("invalid checkpoints interval: %d", checkpointsInterval)
+	}
+
+	err := os.MkdirAll(folder, os.ModePerm)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create state folder %w", err)
+	}
+
+	return &fileStorer{
+		folder:              folder,
+		checkpointsToKeep:   checkpointsToKeep,
+		checkpointsInterval: checkpointsInterval,
+	}, nil
+}
+
+// SaveCheckpoint will write the provided state as the checkpoint of the given epoch
+func (fs *fileStorer) SaveCheckpoint(name string, epoch uint32, state interface{}) error {
+	checkpointsFolder := path.Join(fs.folder, name)
+	err := os.MkdirAll(checkpointsFolder, os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	bytes, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	// write in a temporary file first so an interrupted run never leaves a corrupted checkpoint behind
+	checkpointFile := path.Join(checkpointsFolder, checkpointFileName(epoch))
+	tmpFile := checkpointFile + ".tmp"
+	err = ioutil.WriteFile(tmpFile, bytes, 0644)
+	if err != nil {
+		return err
+	}
+
+	err = os.Rename(tmpFile, checkpointFile)
+	if err != nil {
+		return err
+	}
+
+	return fs.removeOldCheckpoints(name, epoch)
+}
+
+// LoadCheckpoint will read the checkpoint of the given epoch in the provided state
+func (fs *fileStorer) LoadCheckpoint(name string, epoch uint32, state interface{}) error {
+	bytes, err := ioutil.ReadFile(path.Join(fs.folder, name, checkpointFileName(epoch)))
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(bytes, state)
+}
+
+// LastCheckpointEpoch will return the epoch of the most recent checkpoint that is not newer than the provided
+// epoch and false if there is no such checkpoint
+func (fs *fileStorer) LastCheckpointEpoch(name string, maxEpoch uint32) (uint32, bool, error) {
+	epochs, err := fs.checkpointEpochs(name)
+	if err != nil {
+		return 0, false, err
+	}
+
+	for idx := len(epochs) - 1; idx >= 0; idx-- {
+		if epochs[idx] <= maxEpoch {
+			return epochs[idx], true, nil
+		}
+	}
+
+	return 0, false, nil
+}
+
+func (fs *fileStorer) checkpointEpochs(name string) ([]uint32, error) {
+	files, err := ioutil.ReadDir(path.Join(fs.folder, name))
+	if os.IsNotExist(err) {
+		return []uint32{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	epochs := make([]uint32, 0, len(files))
+	for _, file := range files {
+		fileName := file.Name()
+		if file.IsDir() || !strings.HasPrefix(fileName, checkpointPrefix) || !strings.HasSuffix(fileName, checkpointExtension) {
+			continue
+		}
+
+		epochStr := strings.TrimSuffix(strings.TrimPrefix(fileName, checkpointPrefix), checkpointExtension)
+		epoch, errParse := strconv.ParseUint(epochStr, 10, 32)
+		if errParse != nil {
+			continue
+		}
+
+		epochs = append(epochs, uint32(epoch))
+	}
+
+	sort.Slice(epochs, func(i, j int) bool {
+		return epochs[i] < epochs[j]
+	})
+
+	return epochs, nil
+}
+
+// removeOldCheckpoints keeps the checkpoints of the last checkpointsToKeep epochs up to the saved one and all the
+// newer ones, so a run that processes lower epochs again never removes the checkpoints it needs for its restarts.
+// The checkpoints of every checkpointsInterval epochs are kept for the runs that start from an older epoch
+func (fs *fileStorer) removeOldCheckpoints(name string, savedEpoch uint32) error {
+	if fs.checkpointsToKeep == 0 {
+		return nil
+	}
+
+	epochs, err := fs.checkpointEpochs(name)
+	if err != nil {
+		return err
+	}
+
+	for _, epoch := range epochs {
+		if !isCheckpointToRemove(epoch, savedEpoch, fs.checkpointsToKeep, fs.checkpointsInterval) {
+			continue
+		}
+
+		err = os.Remove(path.Join(fs.folder, name, checkpointFileName(epoch)))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func isCheckpointToRemove(epoch uint32, savedEpoch uint32, checkpointsToKeep int, checkpointsInterval int) bool {
+	if checkpointsInterval > 0 && epoch%uint32(checkpointsInterval) == 0 {
+		return false
+	}
+
+	return uint64(epoch)+uint64(checkpointsToKeep) <= uint64(savedEpoch)
+}
+
+func checkpointFileName(epoch uint32) string {
+	return fmt.Sprintf("%s%d%s", checkpointPrefix, epoch, checkpointExtension)
+}
@@ -0,0 +1,115 @@
+package state
+
+import (
+	"math"
+	"math/big"
+	"testing"
+)
+
+type testState struct {
+	LastEpoch uint32              `json:"lastEpoch"`
+	Balances  map[string]*big.Int `json:"balances"`
+}
+
+func TestFileStorer_SaveAndLoadCheckpoint(t *testing.T) {
+	fs, err := NewFileStorer(t.TempDir(), 2, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, found, err := fs.LastCheckpointEpoch("test", math.MaxUint32)
+	if err != nil || found {
+		t.Fatalf("expected no checkpoint, found %v, error %v", found, err)
+	}
+
+	for epoch := uint32(0); epoch < 4; epoch++ {
+		err = fs.SaveCheckpoint("test", epoch, &testState{
+			LastEpoch: epoch,
+			Balances:  map[string]*big.Int{"addr": big.NewInt(int64(epoch) * 10)},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	lastEpoch, found, err := fs.LastCheckpointEpoch("test", math.MaxUint32)
+	if err != nil || !found || lastEpoch != 3 {
+		t.Fatalf("expected last checkpoint 3, got %d, found %v, error %v", lastEpoch, found, err)
+	}
+
+	lastEpoch, found, err = fs.LastCheckpointEpoch("test", 2)
+	if err != nil || !found || lastEpoch != 2 {
+		t.Fatalf("expected checkpoint 2, got %d, found %v, error %v", lastEpoch, found, err)
+	}
+
+	_, found, _ = fs.LastCheckpointEpoch("test", 1)
+	if found {
+		t.Fatal("expected no checkpoint before epoch 2")
+	}
+
+	loaded := &testState{}
+	err = fs.LoadCheckpoint("test", 3, loaded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.LastEpoch != 3 || loaded.Balances["addr"].Cmp(big.NewInt(30)) != 0 {
+		t.Fatalf("unexpected loaded state %+v", loaded)
+	}
+
+	err = fs.LoadCheckpoint("test", 1, loaded)
+	if err == nil {
+		t.Fatal("expected old checkpoint to be removed")
+	}
+}
+
+func TestFileStorer_ReplayedEpochsKeepTheirCheckpoints(t *testing.T) {
+	fs, _ := NewFileStorer(t.TempDir(), 2, 0)
+
+	for _, epoch := range []uint32{10, 11, 3, 4} {
+		err := fs.SaveCheckpoint("test", epoch, &testState{LastEpoch: epoch})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	loaded := &testState{}
+	for _, epoch := range []uint32{3, 4, 10, 11} {
+		err := fs.LoadCheckpoint("test", epoch, loaded)
+		if err != nil {
+			t.Fatalf("expected the checkpoint of epoch %d to be kept, got %v", epoch, err)
+		}
+	}
+
+	_ = fs.SaveCheckpoint("test", 5, &testState{LastEpoch: 5})
+	err := fs.LoadCheckpoint("test", 3, loaded)
+	if err == nil {
+		t.Fatal("expected the checkpoint of epoch 3 to be removed")
+	}
+}
+
+func TestFileStorer_KeepsTheCheckpointsOfTheInterval(t *testing.T) {
+	fs, _ := NewFileStorer(t.TempDir(), 2, 10)
+
+	for epoch := uint32(0); epoch < 25; epoch++ {
+		err := fs.SaveCheckpoint("test", epoch, &testState{LastEpoch: epoch})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	epochs, _ := fs.checkpointEpochs("test")
+	expected := []uint32{0, 10, 20, 23, 24}
+	if len(epochs) != len(expected) {
+		t.Fatalf("expected the checkpoints %v, got %v", expected, epochs)
+	}
+	for idx := range expected {
+		if epochs[idx] != expected[idx] {
+			t.Fatalf("expected the checkpoints %v, got %v", expected, epochs)
+		}
+	}
+
+	checkpointEpoch, found, _ := fs.LastCheckpointEpoch("test", 19)
+	if !found || checkpointEpoch != 10 {
+		t.Fatalf("expected the closest checkpoint 10, got %d, found %v", checkpointEpoch, found)
+	}
+}
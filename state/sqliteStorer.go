@@ -0,0 +1,207 @@
+package state
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+)
+
+var sqliteStorerSchema = []string{
+	`CREATE TABLE IF NOT EXISTS checkpoints (
+		name TEXT NOT NULL,
+		epoch INTEGER NOT NULL,
+		state BLOB NOT NULL,
+		PRIMARY KEY (name, epoch)
+	)`,
+	`CREATE TABLE IF NOT EXISTS stake_balances (
+		epoch INTEGER NOT NULL,
+		address TEXT NOT NULL,
+		balance TEXT NOT NULL,
+		PRIMARY KEY (epoch, address)
+	)`,
+	// an epoch can have no staked balances at all, so the saved epochs are recorded apart from the balances
+	`CREATE TABLE IF NOT EXISTS stake_epochs (
+		epoch INTEGER NOT NULL PRIMARY KEY
+	)`,
+	`INSERT OR IGNORE INTO stake_epochs (epoch) SELECT DISTINCT epoch FROM stake_balances`,
+}
+
+type sqliteStorer struct {
+	db                  *sql.DB
+	checkpointsToKeep   int
+	checkpointsInterval int
+}
+
+// NewSQLiteStorer will create a new instance of sqliteStorer that keeps the checkpoints of the processors and the
+// staked balances of every epoch in the provided database. The checkpoints are kept like in the file storer
+func NewSQLiteStorer(db *sql.DB, checkpointsToKeep int, checkpointsInterval int) (*sqliteStorer, error) {
+	if db == nil {
+		return nil, fmt.Errorf("nil database")
+	}
+	if checkpointsToKeep < 0 {
+		return nil, fmt.Errorf("invalid number of checkpoints to keep: %d", checkpointsToKeep)
+	}
+	if checkpointsInterval < 0 {
+		return nil, fmt.Errorf("invalid checkpoints interval: %d", checkpointsInterval)
+	}
+
+	for _, statement := range sqliteStorerSchema {
+		_, err := db.Exec(statement)
+		if err != nil {
+			return nil, fmt.Errorf("cannot create state tables %w", err)
+		}
+	}
+
+	return &sqliteStorer{
+		db:                  db,
+		checkpointsToKeep:   checkpointsToKeep,
+		checkpointsInterval: checkpointsInterval,
+	}, nil
+}
+
+// SaveCheckpoint will write the provided state as the checkpoint of the given epoch
+func (ss *sqliteStorer) SaveCheckpoint(name string, epoch uint32, state interface{}) error {
+	bytes, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	tx, err := ss.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`INSERT INTO checkpoints (name, epoch, state) VALUES (?, ?, ?)
+		ON CONFLICT (name, epoch) DO UPDATE SET state = excluded.state`, name, epoch, bytes)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	// the checkpoints newer than the saved one and the ones of every checkpointsInterval epochs are kept, like in the
+	// file storer
+	if ss.checkpointsToKeep > 0 {
+		_, err = tx.Exec(`DELETE FROM checkpoints WHERE name = ? AND epoch + ? <= ? AND (? = 0 OR epoch % ? != 0)`,
+			name, ss.checkpointsToKeep, epoch, ss.checkpointsInterval, ss.checkpointsInterval)
+		if err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// LoadCheckpoint will read the checkpoint of the given epoch in the provided state
+func (ss *sqliteStorer) LoadCheckpoint(name string, epoch uint32, state interface{}) error {
+	var bytes []byte
+	err := ss.db.QueryRow(`SELECT state FROM checkpoints WHERE name = ? AND epoch = ?`, name, epoch).Scan(&bytes)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("no %s checkpoint for epoch %d", name, epoch)
+	}
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(bytes, state)
+}
+
+// LastCheckpointEpoch will return the epoch of the most recent checkpoint that is not newer than the provided
+// epoch and false if there is no such checkpoint
+func (ss *sqliteStorer) LastCheckpointEpoch(name string, maxEpoch uint32) (uint32, bool, error) {
+	var epoch sql.NullInt64
+	err := ss.db.QueryRow(`SELECT MAX(epoch) FROM checkpoints WHERE name = ? AND epoch <= ?`, name, maxEpoch).Scan(&epoch)
+	if err != nil {
+		return 0, false, err
+	}
+	if !epoch.Valid {
+		return 0, false, nil
+	}
+
+	return uint32(epoch.Int64), true, nil
+}
+
+// SaveStakeBalances will replace the staked balances of the given epoch
+func (ss *sqliteStorer) SaveStakeBalances(epoch uint32, balances map[string]string) error {
+	tx, err := ss.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`DELETE FROM stake_balances WHERE epoch = ?`, epoch)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	stmt, err := tx.Prepare(`INSERT INTO stake_balances (epoch, address, balance) VALUES (?, ?, ?)`)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	defer func() {
+		_ = stmt.Close()
+	}()
+
+	for address, balance := range balances {
+		_, err = stmt.Exec(epoch, address, balance)
+		if err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+
+	_, err = tx.Exec(`INSERT OR IGNORE INTO stake_epochs (epoch) VALUES (?)`, epoch)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// LoadStakeBalances will return the staked balances of the given epoch, which can be empty
+func (ss *sqliteStorer) LoadStakeBalances(epoch uint32) (map[string]string, error) {
+	exists, err := ss.HasStakeBalances(epoch)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, fmt.Errorf("no stake balances for epoch %d", epoch)
+	}
+
+	rows, err := ss.db.Query(`SELECT address, balance FROM stake_balances WHERE epoch = ?`, epoch)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	balances := map[string]string{}
+	for rows.Next() {
+		var address, balance string
+		err = rows.Scan(&address, &balance)
+		if err != nil {
+			return nil, err
+		}
+
+		balances[address] = balance
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return balances, nil
+}
+
+// HasStakeBalances returns true if the staked balances of the given epoch were saved
+func (ss *sqliteStorer) HasStakeBalances(epoch uint32) (bool, error) {
+	var exists bool
+	err := ss.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM stake_epochs WHERE epoch = ?)`, epoch).Scan(&exists)
+	if err != nil {
+		return false, err
+	}
+
+	return exists, nil
+}
@@ -0,0 +1,179 @@
+package state
+
+import (
+	"math"
+	"math/big"
+	"path"
+	"testing"
+
+	"github.com/ElrondNetwork/statistics-go/sqlite"
+)
+
+func TestSQLiteStorer_SaveAndLoadCheckpoint(t *testing.T) {
+	db, err := sqlite.OpenDatabase(path.Join(t.TempDir(), "statistics.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	ss, err := NewSQLiteStorer(db, 2, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, found, err := ss.LastCheckpointEpoch("test", math.MaxUint32)
+	if err != nil || found {
+		t.Fatalf("expected no checkpoint, found %v, error %v", found, err)
+	}
+
+	for epoch := uint32(0); epoch < 4; epoch++ {
+		err = ss.SaveCheckpoint("test", epoch, &testState{
+			LastEpoch: epoch,
+			Balances:  map[string]*big.Int{"addr": big.NewInt(int64(epoch) * 10)},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	lastEpoch, found, err := ss.LastCheckpointEpoch("test", math.MaxUint32)
+	if err != nil || !found || lastEpoch != 3 {
+		t.Fatalf("expected last checkpoint 3, got %d, found %v, error %v", lastEpoch, found, err)
+	}
+
+	_, found, err = ss.LastCheckpointEpoch("test", 1)
+	if err != nil || found {
+		t.Fatalf("expected the checkpoint of epoch 1 to be removed, found %v, error %v", found, err)
+	}
+
+	loaded := &testState{}
+	err = ss.LoadCheckpoint("test", 2, loaded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.LastEpoch != 2 || loaded.Balances["addr"].Int64() != 20 {
+		t.Fatalf("unexpected loaded state %+v", loaded)
+	}
+
+	err = ss.LoadCheckpoint("test", 0, loaded)
+	if err == nil {
+		t.Fatal("expected error for a removed checkpoint")
+	}
+}
+
+func TestSQLiteStorer_SaveAndLoadStakeBalances(t *testing.T) {
+	db, err := sqlite.OpenDatabase(path.Join(t.TempDir(), "statistics.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	ss, _ := NewSQLiteStorer(db, 0, 0)
+
+	err = ss.SaveStakeBalances(5, map[string]string{"addr1": "10", "addr2": "20"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ss.SaveStakeBalances(5, map[string]string{"addr1": "15"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	balances, err := ss.LoadStakeBalances(5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(balances) != 1 || balances["addr1"] != "15" {
+		t.Fatalf("unexpected balances %v", balances)
+	}
+
+	_, err = ss.LoadStakeBalances(6)
+	if err == nil {
+		t.Fatal("expected error for an epoch without balances")
+	}
+
+	exists5, _ := ss.HasStakeBalances(5)
+	exists6, _ := ss.HasStakeBalances(6)
+	if !exists5 || exists6 {
+		t.Fatalf("expected balances only for epoch 5, got %v and %v", exists5, exists6)
+	}
+}
+
+func TestSQLiteStorer_SaveAndLoadEmptyStakeBalances(t *testing.T) {
+	db, _ := sqlite.OpenDatabase(path.Join(t.TempDir(), "statistics.db"))
+	defer func() {
+		_ = db.Close()
+	}()
+	ss, _ := NewSQLiteStorer(db, 0, 0)
+
+	err := ss.SaveStakeBalances(5, map[string]string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exists, _ := ss.HasStakeBalances(5)
+	if !exists {
+		t.Fatal("expected the empty stake balances of epoch 5")
+	}
+	balances, err := ss.LoadStakeBalances(5)
+	if err != nil || len(balances) != 0 {
+		t.Fatalf("expected no balances for epoch 5, got %v, error %v", balances, err)
+	}
+}
+
+func TestSQLiteStorer_ReplayedEpochsKeepTheirCheckpoints(t *testing.T) {
+	db, _ := sqlite.OpenDatabase(path.Join(t.TempDir(), "statistics.db"))
+	defer func() {
+		_ = db.Close()
+	}()
+	ss, _ := NewSQLiteStorer(db, 2, 0)
+
+	for _, epoch := range []uint32{10, 11, 3, 4} {
+		err := ss.SaveCheckpoint("test", epoch, &testState{LastEpoch: epoch})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	loaded := &testState{}
+	for _, epoch := range []uint32{3, 4, 10, 11} {
+		err := ss.LoadCheckpoint("test", epoch, loaded)
+		if err != nil {
+			t.Fatalf("expected the checkpoint of epoch %d to be kept, got %v", epoch, err)
+		}
+	}
+
+	_ = ss.SaveCheckpoint("test", 5, &testState{LastEpoch: 5})
+	err := ss.LoadCheckpoint("test", 3, loaded)
+	if err == nil {
+		t.Fatal("expected the checkpoint of epoch 3 to be removed")
+	}
+}
+
+func TestSQLiteStorer_KeepsTheCheckpointsOfTheInterval(t *testing.T) {
+	db, _ := sqlite.OpenDatabase(path.Join(t.TempDir(), "statistics.db"))
+	defer func() {
+		_ = db.Close()
+	}()
+	ss, _ := NewSQLiteStorer(db, 2, 10)
+
+	for epoch := uint32(0); epoch < 25; epoch++ {
+		err := ss.SaveCheckpoint("test", epoch, &testState{LastEpoch: epoch})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	checkpointEpoch, found, _ := ss.LastCheckpointEpoch("test", 19)
+	if !found || checkpointEpoch != 10 {
+		t.Fatalf("expected the closest checkpoint 10, got %d, found %v", checkpointEpoch, found)
+	}
+	checkpointEpoch, found, _ = ss.LastCheckpointEpoch("test", 22)
+	if !found || checkpointEpoch != 20 {
+		t.Fatalf("expected the closest checkpoint 20, got %d", checkpointEpoch)
+	}
+}
@@ -0,0 +1,105 @@
+package statistics
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+
+	"github.com/ElrondNetwork/statistics-go/config"
+	"github.com/elastic/go-elasticsearch/v7"
+)
+
+// createElasticConfig builds the settings of the elasticsearch client: the nodes or the cloud ID, at most one
+// authentication method and the TLS settings
+func createElasticConfig(generalCfg config.GeneralConfig) (elasticsearch.Config, error) {
+	addresses := generalCfg.ElasticDatabaseAddresses
+	if len(addresses) == 0 && generalCfg.ElasticDatabaseAddress != "" {
+		addresses = []string{generalCfg.ElasticDatabaseAddress}
+	}
+	if generalCfg.ElasticCloudID != "" && len(addresses) > 0 {
+		return elasticsearch.Config{}, fmt.Errorf("the elasticsearch cloud ID cannot be used together with node addresses")
+	}
+	if generalCfg.ElasticCloudID == "" && len(addresses) == 0 {
+		return elasticsearch.Config{}, fmt.Errorf("no elasticsearch address or cloud ID")
+	}
+
+	authMethods := 0
+	for _, credential := range []string{generalCfg.Username, generalCfg.ElasticAPIKey, generalCfg.ElasticBearerToken} {
+		if credential != "" {
+			authMethods++
+		}
+	}
+	if authMethods > 1 {
+		return elasticsearch.Config{}, fmt.Errorf("only one of username, API key and bearer token can be used for elasticsearch")
+	}
+
+	elasticCfg := elasticsearch.Config{
+		Addresses: addresses,
+		CloudID:   generalCfg.ElasticCloudID,
+		Username:  generalCfg.Username,
+		Password:  generalCfg.Password,
+		APIKey:    generalCfg.ElasticAPIKey,
+	}
+	if generalCfg.ElasticBearerToken != "" {
+		elasticCfg.Header = http.Header{
+			"Authorization": []string{"Bearer " + generalCfg.ElasticBearerToken},
+		}
+	}
+
+	tlsConfig, err := createElasticTLSConfig(generalCfg)
+	if err != nil {
+		return elasticsearch.Config{}, err
+	}
+	if tlsConfig != nil {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = tlsConfig
+		elasticCfg.Transport = transport
+	}
+
+	return elasticCfg, nil
+}
+
+// createElasticTLSConfig returns nil when no TLS setting is configured, so the default transport is used
+func createElasticTLSConfig(generalCfg config.GeneralConfig) (*tls.Config, error) {
+	hasClientCert := generalCfg.ElasticClientCertFile != "" || generalCfg.ElasticClientKeyFile != ""
+	if generalCfg.ElasticCACertFile == "" && !hasClientCert && !generalCfg.ElasticTLSSkipVerify {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: generalCfg.ElasticTLSSkipVerify,
+	}
+	if generalCfg.ElasticTLSSkipVerify {
+		log.Printf("the certificate of the elasticsearch nodes is not verified")
+	}
+
+	if generalCfg.ElasticCACertFile != "" {
+		caBytes, err := ioutil.ReadFile(generalCfg.ElasticCACertFile)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read the elasticsearch CA bundle: %w", err)
+		}
+
+		rootCAs := x509.NewCertPool()
+		if !rootCAs.AppendCertsFromPEM(caBytes) {
+			return nil, fmt.Errorf("no certificate in the elasticsearch CA bundle %s", generalCfg.ElasticCACertFile)
+		}
+		tlsConfig.RootCAs = rootCAs
+	}
+
+	if hasClientCert {
+		if generalCfg.ElasticClientCertFile == "" || generalCfg.ElasticClientKeyFile == "" {
+			return nil, fmt.Errorf("the elasticsearch client certificate needs both the certificate and the key files")
+		}
+
+		clientCert, err := tls.LoadX509KeyPair(generalCfg.ElasticClientCertFile, generalCfg.ElasticClientKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("cannot load the elasticsearch client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{clientCert}
+	}
+
+	return tlsConfig, nil
+}
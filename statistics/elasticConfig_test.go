@@ -0,0 +1,88 @@
+package statistics
+
+import (
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"testing"
+
+	"github.com/ElrondNetwork/elrond-go/core"
+	"github.com/ElrondNetwork/statistics-go/config"
+	"github.com/elastic/go-elasticsearch/v7"
+)
+
+func TestCreateElasticConfig_CABundleAndBearerToken(t *testing.T) {
+	authorization := make(chan string, 1)
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization <- r.Header.Get("Authorization")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	caFile := path.Join(t.TempDir(), "ca.pem")
+	caBytes := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	err := ioutil.WriteFile(caFile, caBytes, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	elasticCfg, err := createElasticConfig(config.GeneralConfig{
+		ElasticDatabaseAddress:   "http://unused:9200",
+		ElasticDatabaseAddresses: []string{server.URL},
+		ElasticBearerToken:       "token",
+		ElasticCACertFile:        caFile,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(elasticCfg.Addresses) != 1 || elasticCfg.Addresses[0] != server.URL {
+		t.Fatalf("expected the node addresses to replace the single address, got %v", elasticCfg.Addresses)
+	}
+
+	client, _ := elasticsearch.NewClient(elasticCfg)
+	res, err := client.Info()
+	if err != nil {
+		t.Fatalf("the server certificate was not trusted: %v", err)
+	}
+	_ = res.Body.Close()
+
+	if header := <-authorization; header != "Bearer token" {
+		t.Fatalf("unexpected authorization header %q", header)
+	}
+}
+
+func TestCreateElasticConfig_InvalidSettings(t *testing.T) {
+	invalidConfigs := map[string]config.GeneralConfig{
+		"no address":              {},
+		"cloud ID and addresses":  {ElasticDatabaseAddress: "http://localhost:9200", ElasticCloudID: "name:ZXhhbXBsZS5jb20kZXMkJGtpYmFuYQ=="},
+		"two auth methods":        {ElasticDatabaseAddress: "http://localhost:9200", Username: "user", ElasticAPIKey: "key"},
+		"missing CA bundle":       {ElasticDatabaseAddress: "http://localhost:9200", ElasticCACertFile: path.Join(t.TempDir(), "missing.pem")},
+		"client cert without key": {ElasticDatabaseAddress: "http://localhost:9200", ElasticClientCertFile: "client.pem"},
+	}
+
+	for name, generalCfg := range invalidConfigs {
+		_, err := createElasticConfig(generalCfg)
+		if err == nil {
+			t.Fatalf("expected error for %s", name)
+		}
+	}
+}
+
+func TestCreateElasticConfig_DefaultConfigFile(t *testing.T) {
+	cfg := &config.Config{}
+	err := core.LoadTomlFile(cfg, "../cmd/statistics/config/config.toml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	elasticCfg, err := createElasticConfig(cfg.GeneralConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(elasticCfg.Addresses) != 1 || elasticCfg.Transport != nil {
+		t.Fatalf("expected a single node over the default transport, got %v", elasticCfg.Addresses)
+	}
+}
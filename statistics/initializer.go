@@ -1,30 +1,62 @@
 package statistics
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
+	"os"
+	"path"
+	"time"
 
 	"github.com/ElrondNetwork/elrond-go/data/state/factory"
 	"github.com/ElrondNetwork/statistics-go/config"
 	"github.com/ElrondNetwork/statistics-go/data"
 	"github.com/ElrondNetwork/statistics-go/elasticClient"
+	"github.com/ElrondNetwork/statistics-go/output"
 	"github.com/ElrondNetwork/statistics-go/process"
 	"github.com/ElrondNetwork/statistics-go/restClient"
-	"github.com/elastic/go-elasticsearch/v7"
+	"github.com/ElrondNetwork/statistics-go/retry"
+	"github.com/ElrondNetwork/statistics-go/sqlite"
+	"github.com/ElrondNetwork/statistics-go/state"
 	"github.com/tidwall/gjson"
 )
 
-func CreateStatsHandler(cfg *config.Config, pathGenesisFiles string) (StatsHandler, error) {
-	elasticCfg := elasticsearch.Config{
-		Addresses: []string{cfg.GeneralConfig.ElasticDatabaseAddress},
-		Username:  cfg.GeneralConfig.Username,
-		Password:  cfg.GeneralConfig.Password,
+// OpenDatabase will open the sqlite database shared by the sinks and the storers, it returns nil when the database
+// is not enabled. The caller closes the database when the command exits
+func OpenDatabase(cfg *config.Config) (*sql.DB, error) {
+	if !cfg.SQLiteConfig.Enabled {
+		return nil, nil
 	}
-	esClient, err := elasticClient.NewElasticClient(elasticCfg)
+
+	return sqlite.OpenDatabase(cfg.SQLiteConfig.DatabasePath)
+}
+
+// CreateStatsHandler will create the generator of statistics. When a metrics handler is provided, it receives the
+// statistics of the latest processed epoch and the processing metrics. The context bounds the requests done at startup
+// and the database is the one returned by OpenDatabase
+func CreateStatsHandler(ctx context.Context, cfg *config.Config, db *sql.DB, metrics MetricsHandler) (StatsHandler, error) {
+	err := checkFolders(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	indices, err := process.NewIndices(cfg.IndicesConfig)
 	if err != nil {
 		return nil, err
 	}
 
-	rClient, err := restClient.NewRestClient(cfg.GeneralConfig.APIUrl)
+	esClient, err := createElasticClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if metrics != nil {
+		esClient = &elasticHandlerWithMetrics{
+			elasticHandler: esClient,
+			metrics:        metrics,
+		}
+	}
+
+	rClient, err := createRestClient(cfg)
 	if err != nil {
 		return nil, err
 	}
@@ -34,40 +66,308 @@ func CreateStatsHandler(cfg *config.Config, pathGenesisFiles string) (StatsHandl
 		return nil, err
 	}
 
-	genesisTime, err := fetchGenesisTime(rClient)
+	genesisTime, err := fetchGenesisTime(ctx, rClient)
+	if err != nil {
+		return nil, err
+	}
+
+	stateStorer, err := createStateStorer(cfg, db)
+	if err != nil {
+		return nil, err
+	}
+
+	balancesStorer, err := CreateStakeBalancesStorer(cfg, db)
+	if err != nil {
+		return nil, err
+	}
+
+	epochBoundaries, err := createEpochBoundariesHandler(cfg.GeneralConfig.EpochBoundariesSource, esClient, rClient, indices.Blocks, genesisTime)
+	if err != nil {
+		return nil, err
+	}
+
+	// the same holder is used by both processors so the staked balances are passed in memory when they run together
+	stakeBalances, err := process.NewStakeBalancesHolder(balancesStorer)
 	if err != nil {
 		return nil, err
 	}
 
-	acctsHandler, err := process.NewAccountsProcessor(esClient, pubKeyConverter, genesisTime)
+	accountsExclusion, err := process.NewAddressesExclusion(cfg.AccountsExclusions)
+	if err != nil {
+		return nil, err
+	}
+
+	balanceBuckets, err := process.NewBalanceBuckets(cfg.StatisticsConfig.BalanceBuckets)
+	if err != nil {
+		return nil, err
+	}
+
+	// the storer already rejected a negative interval
+	checkpointsInterval := uint32(cfg.StateConfig.CheckpointsInterval)
+
+	var epochMetrics process.EpochMetricsHandler = process.NewDisabledEpochMetrics()
+	if metrics != nil {
+		epochMetrics = metrics
+	}
+
+	acctsHandler, err := process.NewAccountsProcessor(
+		esClient,
+		stateStorer,
+		stakeBalances,
+		accountsExclusion,
+		balanceBuckets,
+		pubKeyConverter,
+		epochBoundaries,
+		checkpointsInterval,
+		epochMetrics,
+		indices,
+		cfg.StatisticsConfig.Strict,
+	)
 	if err != nil {
 		return nil, err
 	}
 
 	stakeInfoHandler, err := process.NewStakeInfoProcessor(
 		esClient,
+		stateStorer,
+		stakeBalances,
+		balanceBuckets,
 		rClient,
 		pubKeyConverter,
-		pathGenesisFiles,
-		genesisTime,
+		cfg.GeneralConfig.GenesisFolder,
+		epochBoundaries,
 		cfg.GeneralConfig.DelegationLegacyContractAddress,
 		cfg.GeneralConfig.StakingContractAddress,
+		checkpointsInterval,
+		epochMetrics,
+		indices,
+		cfg.StatisticsConfig.Strict,
 	)
 	if err != nil {
 		return nil, err
 	}
 
-	transactionsHandler, err := process.NewTransactionsProcessor(esClient, pubKeyConverter, pathGenesisFiles, genesisTime)
+	transactionsHandler, err := process.NewTransactionsProcessor(
+		esClient,
+		stateStorer,
+		pubKeyConverter,
+		cfg.GeneralConfig.GenesisFolder,
+		epochBoundaries,
+		cfg.StatisticsConfig.TopAddressesCount,
+		cfg.StatisticsConfig.ActiveAddressesFolder,
+		cfg.StatisticsConfig.TransactionsWorkers,
+		cfg.StatisticsConfig.TransactionsScrollSlices,
+		cfg.StatisticsConfig.TransactionsCountingMode,
+		cfg.StatisticsConfig.TransactionsComparisonReport,
+		checkpointsInterval,
+		epochMetrics,
+		indices,
+		cfg.StatisticsConfig.Strict,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	statsHandler, err := process.NewStatisticsProcessor(transactionsHandler, acctsHandler, stakeInfoHandler)
+	if err != nil {
+		return nil, err
+	}
+	if metrics == nil {
+		return statsHandler, nil
+	}
+
+	return &statsHandlerWithMetrics{
+		statsHandler: statsHandler,
+		metrics:      metrics,
+	}, nil
+}
+
+// CreateSinks will create the destinations where the statistics are published besides the output files
+func CreateSinks(cfg *config.Config, db *sql.DB) ([]output.Sink, error) {
+	sinks := make([]output.Sink, 0)
+	if cfg.ElasticSinkConfig.Enabled {
+		esClient, err := createElasticClient(cfg)
+		if err != nil {
+			return nil, err
+		}
+
+		elasticSink, err := output.NewElasticSink(
+			esClient,
+			cfg.ElasticSinkConfig.TransactionsIndex,
+			cfg.ElasticSinkConfig.AccountsIndex,
+			cfg.ElasticSinkConfig.StakeIndex,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		sinks = append(sinks, elasticSink)
+	}
+
+	if db != nil {
+		sqliteSink, err := output.NewSQLiteSink(db)
+		if err != nil {
+			return nil, err
+		}
+
+		sinks = append(sinks, sqliteSink)
+	}
+
+	return sinks, nil
+}
+
+// CreateCurrentEpochHandler will create the source of the current epoch used to detect the closed epochs
+func CreateCurrentEpochHandler(cfg *config.Config) (process.CurrentEpochHandler, error) {
+	switch cfg.DaemonConfig.CurrentEpochSource {
+	case process.CurrentEpochGateway, "":
+		rClient, err := createRestClient(cfg)
+		if err != nil {
+			return nil, err
+		}
+
+		return process.NewGatewayCurrentEpoch(rClient)
+	case process.CurrentEpochElastic:
+		indices, err := process.NewIndices(cfg.IndicesConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		esClient, err := createElasticClient(cfg)
+		if err != nil {
+			return nil, err
+		}
+
+		return process.NewElasticCurrentEpoch(esClient, indices.Blocks)
+	default:
+		return nil, fmt.Errorf("invalid current epoch source %s, expected %s or %s",
+			cfg.DaemonConfig.CurrentEpochSource, process.CurrentEpochGateway, process.CurrentEpochElastic)
+	}
+}
+
+// CreateStakeBalancesStorer will create the storer of the staked balances of every epoch, in the sqlite database
+// or in the configured folder
+func CreateStakeBalancesStorer(cfg *config.Config, db *sql.DB) (process.StakeBalancesStorer, error) {
+	if db == nil {
+		return state.NewBalancesFileStorer(cfg.GeneralConfig.StakeBalancesFolder)
+	}
+
+	return state.NewSQLiteStorer(db, cfg.StateConfig.CheckpointsToKeep, cfg.StateConfig.CheckpointsInterval)
+}
+
+func createStateStorer(cfg *config.Config, db *sql.DB) (process.StateStorer, error) {
+	if !cfg.SQLiteConfig.StoreState {
+		return state.NewFileStorer(cfg.StateConfig.Folder, cfg.StateConfig.CheckpointsToKeep, cfg.StateConfig.CheckpointsInterval)
+	}
+	if db == nil {
+		return nil, fmt.Errorf("the state can be stored in the sqlite database only when the database is enabled")
+	}
+
+	return state.NewSQLiteStorer(db, cfg.StateConfig.CheckpointsToKeep, cfg.StateConfig.CheckpointsInterval)
+}
+
+func createElasticClient(cfg *config.Config) (elasticHandler, error) {
+	elasticCfg, err := createElasticConfig(cfg.GeneralConfig)
 	if err != nil {
 		return nil, err
 	}
 
-	return process.NewStatisticsProcessor(transactionsHandler, acctsHandler, stakeInfoHandler)
+	retryPolicy, err := createRetryPolicy(cfg.ElasticRetryConfig)
+	if err != nil {
+		return nil, fmt.Errorf("elastic retry config: %w", err)
+	}
+
+	return elasticClient.NewElasticClient(
+		elasticCfg,
+		secondsToDuration(cfg.GeneralConfig.ElasticRequestTimeoutInSeconds),
+		retryPolicy,
+		cfg.GeneralConfig.ElasticMaxParallelSlices,
+		cfg.GeneralConfig.ElasticPagination,
+	)
+}
+
+func createRestClient(cfg *config.Config) (process.RestClientHandler, error) {
+	retryPolicy, err := createRetryPolicy(cfg.APIRetryConfig)
+	if err != nil {
+		return nil, fmt.Errorf("API retry config: %w", err)
+	}
+
+	return restClient.NewRestClient(cfg.GeneralConfig.APIUrl, secondsToDuration(cfg.GeneralConfig.APIRequestTimeoutInSeconds), retryPolicy)
+}
+
+func createRetryPolicy(retryCfg config.RetryConfig) (retryHandler, error) {
+	return retry.NewPolicy(
+		retryCfg.MaxAttempts,
+		millisecondsToDuration(retryCfg.InitialBackoffInMilliseconds),
+		millisecondsToDuration(retryCfg.MaxBackoffInMilliseconds),
+		retryCfg.RetryableStatusCodes,
+	)
+}
+
+func secondsToDuration(seconds int) time.Duration {
+	return time.Duration(seconds) * time.Second
+}
+
+func millisecondsToDuration(milliseconds int) time.Duration {
+	return time.Duration(milliseconds) * time.Millisecond
+}
+
+// checkFolders verifies that the genesis files exist and creates the folders where the processors write
+func checkFolders(cfg *config.Config) error {
+	for _, genesisFile := range []string{"genesis.json", "nodesSetup.json"} {
+		pathGenesisFile := path.Join(cfg.GeneralConfig.GenesisFolder, genesisFile)
+		info, err := os.Stat(pathGenesisFile)
+		if err != nil {
+			return fmt.Errorf("invalid genesis folder %s: %w", cfg.GeneralConfig.GenesisFolder, err)
+		}
+		if info.IsDir() {
+			return fmt.Errorf("invalid genesis folder %s: %s is a directory", cfg.GeneralConfig.GenesisFolder, genesisFile)
+		}
+	}
+
+	for _, folder := range []string{cfg.GeneralConfig.StakeBalancesFolder, cfg.StateConfig.Folder} {
+		if folder == "" {
+			return fmt.Errorf("empty folder path in configuration")
+		}
+
+		err := os.MkdirAll(folder, os.ModePerm)
+		if err != nil {
+			return fmt.Errorf("cannot create folder %s: %w", folder, err)
+		}
+	}
+
+	if cfg.StatisticsConfig.ActiveAddressesFolder != "" {
+		err := os.MkdirAll(cfg.StatisticsConfig.ActiveAddressesFolder, os.ModePerm)
+		if err != nil {
+			return fmt.Errorf("cannot create folder %s: %w", cfg.StatisticsConfig.ActiveAddressesFolder, err)
+		}
+	}
+
+	return nil
+}
+
+func createEpochBoundariesHandler(
+	source string,
+	esClient process.ElasticHandler,
+	rClient process.RestClientHandler,
+	blocksIndex string,
+	genesisTime int,
+) (process.EpochBoundariesHandler, error) {
+	switch source {
+	case process.EpochBoundariesElastic:
+		return process.NewElasticEpochBoundaries(esClient, blocksIndex, genesisTime)
+	case process.EpochBoundariesGateway:
+		return process.NewGatewayEpochBoundaries(rClient, genesisTime)
+	case process.EpochBoundariesFixed, "":
+		return process.NewFixedEpochBoundaries(genesisTime)
+	default:
+		return nil, fmt.Errorf("invalid epoch boundaries source %s, expected %s, %s or %s",
+			source, process.EpochBoundariesElastic, process.EpochBoundariesGateway, process.EpochBoundariesFixed)
+	}
 }
 
-func fetchGenesisTime(rClient process.RestClientHandler) (int, error) {
+func fetchGenesisTime(ctx context.Context, rClient process.RestClientHandler) (int, error) {
 	genericAPIResponse := &data.GenericAPIResponse{}
-	err := rClient.CallGetRestEndPoint("/network/config", genericAPIResponse)
+	err := rClient.CallGetRestEndPoint(ctx, "/network/config", genericAPIResponse)
 	if err != nil {
 		return 0, err
 	}
@@ -1,7 +1,38 @@
 package statistics
 
+import (
+	"context"
+	"time"
+
+	"github.com/ElrondNetwork/statistics-go/data"
+	"github.com/ElrondNetwork/statistics-go/output"
+	"github.com/ElrondNetwork/statistics-go/process"
+)
+
+// StatsHandler defines what a generator of statistics should be able to do. The results are returned as typed
+// records, one for every processed epoch, and can be serialized with the output package
 type StatsHandler interface {
-	ProcessAllAccounts(endEpoch uint32) ([]byte, error)
-	ProcessAllTransactions(endEpoch uint32) ([]byte, error)
-	ProcessStakeInfo(endEpoch uint32) ([]byte, error)
+	ProcessAllAccounts(ctx context.Context, startEpoch, endEpoch uint32, resume bool) ([]*data.StatisticsAddressesBalanceEpoch, error)
+	ProcessAllTransactions(ctx context.Context, startEpoch, endEpoch uint32, resume bool) ([]*data.StatisticsEpoch, error)
+	ProcessStakeInfo(ctx context.Context, startEpoch, endEpoch uint32, resume bool) ([]*data.StakeInfoEpoch, error)
+}
+
+// elasticHandler is the elasticsearch client used both for reading the indexed data and for publishing the statistics
+type elasticHandler interface {
+	process.ElasticHandler
+	output.ElasticBulkHandler
+}
+
+// retryHandler retries the failed requests of both the elasticsearch and the gateway API clients
+type retryHandler interface {
+	Do(ctx context.Context, operation string, action func() error) error
+}
+
+// MetricsHandler defines what a collector of the statistics and of the processing metrics should be able to do
+type MetricsHandler interface {
+	ScrollPageFetched(index string)
+	EpochProcessed(statistic string, duration time.Duration, failed bool)
+	SetLatestTransactions(record *data.StatisticsEpoch)
+	SetLatestAccounts(record *data.StatisticsAddressesBalanceEpoch)
+	SetLatestStake(record *data.StakeInfoEpoch)
 }
@@ -0,0 +1,106 @@
+package statistics
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/ElrondNetwork/statistics-go/data"
+)
+
+// elasticHandlerWithMetrics counts the scroll pages fetched from elasticsearch
+type elasticHandlerWithMetrics struct {
+	elasticHandler
+	metrics MetricsHandler
+}
+
+// DoScrollRequestAllDocuments will count every page before passing it to the provided handler
+func (ehm *elasticHandlerWithMetrics) DoScrollRequestAllDocuments(
+	ctx context.Context,
+	query *bytes.Buffer,
+	index string,
+	handlerFunc func(responseBytes []byte) error,
+) error {
+	return ehm.elasticHandler.DoScrollRequestAllDocuments(ctx, query, index, func(responseBytes []byte) error {
+		ehm.metrics.ScrollPageFetched(index)
+		return handlerFunc(responseBytes)
+	})
+}
+
+// DoSlicedScrollRequestAllDocuments will count every page of every slice before passing it to the provided handler
+func (ehm *elasticHandlerWithMetrics) DoSlicedScrollRequestAllDocuments(
+	ctx context.Context,
+	query *bytes.Buffer,
+	index string,
+	numSlices int,
+	handlerFunc func(responseBytes []byte) error,
+) error {
+	return ehm.elasticHandler.DoSlicedScrollRequestAllDocuments(ctx, query, index, numSlices, func(responseBytes []byte) error {
+		ehm.metrics.ScrollPageFetched(index)
+		return handlerFunc(responseBytes)
+	})
+}
+
+// statsHandlerWithMetrics publishes the statistics of the latest epoch that did not fail, the processors record the
+// metrics of every epoch themselves
+type statsHandlerWithMetrics struct {
+	statsHandler StatsHandler
+	metrics      MetricsHandler
+}
+
+// ProcessAllAccounts will process the accounts statistics and publish the ones of the latest epoch that did not fail
+func (shm *statsHandlerWithMetrics) ProcessAllAccounts(ctx context.Context, startEpoch, endEpoch uint32, resume bool) ([]*data.StatisticsAddressesBalanceEpoch, error) {
+	// the epochs completed before an interruption are returned together with the error
+	records, err := shm.statsHandler.ProcessAllAccounts(ctx, startEpoch, endEpoch, resume)
+	PublishLatestAccounts(shm.metrics, records)
+
+	return records, err
+}
+
+// ProcessAllTransactions will process the transactions statistics and publish the ones of the latest epoch that did not fail
+func (shm *statsHandlerWithMetrics) ProcessAllTransactions(ctx context.Context, startEpoch, endEpoch uint32, resume bool) ([]*data.StatisticsEpoch, error) {
+	// the epochs completed before an interruption are returned together with the error
+	records, err := shm.statsHandler.ProcessAllTransactions(ctx, startEpoch, endEpoch, resume)
+	PublishLatestTransactions(shm.metrics, records)
+
+	return records, err
+}
+
+// ProcessStakeInfo will process the stake statistics and publish the ones of the latest epoch that did not fail
+func (shm *statsHandlerWithMetrics) ProcessStakeInfo(ctx context.Context, startEpoch, endEpoch uint32, resume bool) ([]*data.StakeInfoEpoch, error) {
+	// the epochs completed before an interruption are returned together with the error
+	records, err := shm.statsHandler.ProcessStakeInfo(ctx, startEpoch, endEpoch, resume)
+	PublishLatestStake(shm.metrics, records)
+
+	return records, err
+}
+
+// PublishLatestAccounts sets the metrics to the accounts statistics of the latest epoch that did not fail, since the
+// values of a failed epoch are partial. The records saved before the epochs had a status did not fail
+func PublishLatestAccounts(metrics MetricsHandler, records []*data.StatisticsAddressesBalanceEpoch) {
+	for idx := len(records) - 1; idx >= 0; idx-- {
+		if records[idx].Status != data.EpochStatusFailed {
+			metrics.SetLatestAccounts(records[idx])
+			return
+		}
+	}
+}
+
+// PublishLatestTransactions sets the metrics to the transactions statistics of the latest epoch that did not fail
+func PublishLatestTransactions(metrics MetricsHandler, records []*data.StatisticsEpoch) {
+	for idx := len(records) - 1; idx >= 0; idx-- {
+		if records[idx].Status != data.EpochStatusFailed {
+			metrics.SetLatestTransactions(records[idx])
+			return
+		}
+	}
+}
+
+// PublishLatestStake sets the metrics to the stake statistics of the latest epoch that did not fail
+func PublishLatestStake(metrics MetricsHandler, records []*data.StakeInfoEpoch) {
+	for idx := len(records) - 1; idx >= 0; idx-- {
+		if records[idx].Status != data.EpochStatusFailed {
+			metrics.SetLatestStake(records[idx])
+			return
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package statistics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ElrondNetwork/statistics-go/data"
+)
+
+// metricsStub records the published accounts statistics
+type metricsStub struct {
+	latestAccounts *data.StatisticsAddressesBalanceEpoch
+}
+
+func (ms *metricsStub) ScrollPageFetched(_ string) {
+}
+
+func (ms *metricsStub) EpochProcessed(_ string, _ time.Duration, _ bool) {
+}
+
+func (ms *metricsStub) SetLatestTransactions(_ *data.StatisticsEpoch) {
+}
+
+func (ms *metricsStub) SetLatestAccounts(record *data.StatisticsAddressesBalanceEpoch) {
+	ms.latestAccounts = record
+}
+
+func (ms *metricsStub) SetLatestStake(_ *data.StakeInfoEpoch) {
+}
+
+func TestPublishLatestAccounts_SkipsTheFailedEpochs(t *testing.T) {
+	tests := []struct {
+		name          string
+		statuses      []string
+		expectedEpoch int
+	}{
+		{name: "last epoch ok", statuses: []string{data.EpochStatusOK, data.EpochStatusOK}, expectedEpoch: 1},
+		{name: "last epoch failed", statuses: []string{data.EpochStatusOK, data.EpochStatusFailed}, expectedEpoch: 0},
+		{name: "record without status", statuses: []string{"", data.EpochStatusFailed}, expectedEpoch: 0},
+		{name: "all epochs failed", statuses: []string{data.EpochStatusFailed, data.EpochStatusFailed}, expectedEpoch: -1},
+		{name: "no records", statuses: nil, expectedEpoch: -1},
+	}
+
+	for _, test := range tests {
+		records := make([]*data.StatisticsAddressesBalanceEpoch, 0, len(test.statuses))
+		for epoch, status := range test.statuses {
+			records = append(records, &data.StatisticsAddressesBalanceEpoch{Epoch: uint32(epoch), Status: status})
+		}
+
+		metrics := &metricsStub{}
+		PublishLatestAccounts(metrics, records)
+
+		if test.expectedEpoch < 0 {
+			if metrics.latestAccounts != nil {
+				t.Fatalf("%s: expected nothing published, got epoch %d", test.name, metrics.latestAccounts.Epoch)
+			}
+			continue
+		}
+		if metrics.latestAccounts == nil || metrics.latestAccounts.Epoch != uint32(test.expectedEpoch) {
+			t.Fatalf("%s: expected epoch %d published, got %v", test.name, test.expectedEpoch, metrics.latestAccounts)
+		}
+	}
+}